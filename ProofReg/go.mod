@@ -2,12 +2,19 @@ module double_auction
 
 go 1.23.0
 
+require github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism v0.0.0
+
+replace github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism => ..
+
+require (
+	github.com/consensys/gnark v0.11.0
+	github.com/consensys/gnark-crypto v0.14.0
+)
+
 require (
 	github.com/bits-and-blooms/bitset v1.14.2 // indirect
 	github.com/blang/semver/v4 v4.0.0 // indirect
 	github.com/consensys/bavard v0.1.13 // indirect
-	github.com/consensys/gnark v0.11.0 // indirect
-	github.com/consensys/gnark-crypto v0.14.0 // indirect
 	github.com/fxamacker/cbor/v2 v2.7.0 // indirect
 	github.com/google/pprof v0.0.0-20240727154555-813a5fbdbec8 // indirect
 	github.com/ingonyama-zk/icicle v1.1.0 // indirect
@@ -0,0 +1,79 @@
+// Package aead encrypts field elements to the auctioneer, with a MiMC duplex
+// keyed by the point g_r_b shared with it. The state is initialised with the
+// key and a nonce, each plaintext element is masked with the state then the
+// ciphertext element is absorbed, and the tag is squeezed once the whole
+// ciphertext is absorbed:
+//
+//	s_0 = H(g_r_b.X, g_r_b.Y, nonce)
+//	c_i = m_i + s_i, s_(i+1) = H(s_i, c_i)
+//	tag = H(s_n, n)
+//
+// Changing any element of the ciphertext changes every later state and the
+// tag, so that a ciphertext can't be modified without the key.
+//
+// The key g_r_b is fresh for every registration and every transfer, the nonce
+// only separates the layouts of the plaintexts encrypted under the same key.
+//
+// Every gadget has a native counterpart (see native.go) computing the same
+// value outside of the circuit.
+package aead
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// nonces of the plaintexts
+const (
+	// (nk_in, pk_out, b_0, b_1, ...) of ProofReg, ProofDraw and ProofF
+	NonceRegistration = 1
+	// (nk_old_1, ..., nk_old_l, pk_new_1, ..., pk_new_m, b) of ProofTx
+	NonceTransfer = 2
+)
+
+// Encrypt returns the ciphertext and the tag of the plaintext under the key
+// g_r_b and the nonce
+func Encrypt(api frontend.API, G_r_b twistededwards.Point, nonce int, plaintext []frontend.Variable) ([]frontend.Variable, frontend.Variable) {
+	state := initialize(api, G_r_b, nonce)
+	ciphertext := make([]frontend.Variable, len(plaintext))
+	for i := range plaintext {
+		ciphertext[i] = api.Add(plaintext[i], state)
+		state = absorb(api, state, ciphertext[i])
+	}
+	return ciphertext, squeeze(api, state, len(plaintext))
+}
+
+// Decrypt returns the plaintext of the ciphertext under the key g_r_b and the
+// nonce, and asserts that the tag authenticates the ciphertext
+func Decrypt(api frontend.API, G_r_b twistededwards.Point, nonce int, ciphertext []frontend.Variable, tag frontend.Variable) []frontend.Variable {
+	state := initialize(api, G_r_b, nonce)
+	plaintext := make([]frontend.Variable, len(ciphertext))
+	for i := range ciphertext {
+		plaintext[i] = api.Sub(ciphertext[i], state)
+		state = absorb(api, state, ciphertext[i])
+	}
+	api.AssertIsEqual(tag, squeeze(api, state, len(ciphertext)))
+	return plaintext
+}
+
+// initialize returns s_0 = H(g_r_b.X, g_r_b.Y, nonce)
+func initialize(api frontend.API, G_r_b twistededwards.Point, nonce int) frontend.Variable {
+	s_mimc, _ := mimc.NewMiMC(api)
+	s_mimc.Write(G_r_b.X, G_r_b.Y, nonce)
+	return s_mimc.Sum()
+}
+
+// absorb returns s_(i+1) = H(s_i, c_i)
+func absorb(api frontend.API, state, c frontend.Variable) frontend.Variable {
+	s_mimc, _ := mimc.NewMiMC(api)
+	s_mimc.Write(state, c)
+	return s_mimc.Sum()
+}
+
+// squeeze returns the tag H(s_n, n)
+func squeeze(api frontend.API, state frontend.Variable, n int) frontend.Variable {
+	tag_mimc, _ := mimc.NewMiMC(api)
+	tag_mimc.Write(state, n)
+	return tag_mimc.Sum()
+}
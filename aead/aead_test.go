@@ -0,0 +1,154 @@
+package aead
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/test"
+)
+
+// aeadCircuit encrypts the plaintext and decrypts the ciphertext under the
+// same key, asserting the results
+type aeadCircuit struct {
+	G_r_b      twistededwards.Point
+	Plaintext  []frontend.Variable
+	Ciphertext []frontend.Variable `gnark:",public"`
+	Tag        frontend.Variable   `gnark:",public"`
+	nonce      int
+}
+
+func (c *aeadCircuit) Define(api frontend.API) error {
+	C, Tag := Encrypt(api, c.G_r_b, c.nonce, c.Plaintext)
+	for i := range C {
+		api.AssertIsEqual(c.Ciphertext[i], C[i])
+	}
+	api.AssertIsEqual(c.Tag, Tag)
+	m := Decrypt(api, c.G_r_b, c.nonce, c.Ciphertext, c.Tag)
+	for i := range m {
+		api.AssertIsEqual(c.Plaintext[i], m[i])
+	}
+	return nil
+}
+
+func newAEADCircuit(n, nonce int) *aeadCircuit {
+	return &aeadCircuit{Plaintext: make([]frontend.Variable, n), Ciphertext: make([]frontend.Variable, n), nonce: nonce}
+}
+
+// decryptCircuit only decrypts the ciphertext
+type decryptCircuit struct {
+	G_r_b      twistededwards.Point
+	Ciphertext []frontend.Variable `gnark:",public"`
+	Tag        frontend.Variable   `gnark:",public"`
+	nonce      int
+}
+
+func (c *decryptCircuit) Define(api frontend.API) error {
+	Decrypt(api, c.G_r_b, c.nonce, c.Ciphertext, c.Tag)
+	return nil
+}
+
+// randomKey returns a random point of the twisted Edwards curve
+func randomKey(t *testing.T) edwards.PointAffine {
+	t.Helper()
+	var s fr.Element
+	if _, err := s.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	curve := edwards.GetEdwardsCurve()
+	var p edwards.PointAffine
+	b := s.BigInt(new(big.Int))
+	p.ScalarMultiplication(&curve.Base, b.Mod(b, &curve.Order))
+	return p
+}
+
+func randomPlaintext(t *testing.T, n int) []fr.Element {
+	t.Helper()
+	m := make([]fr.Element, n)
+	for i := range m {
+		if _, err := m[i].SetRandom(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestNative(t *testing.T) {
+	key, m := randomKey(t), randomPlaintext(t, 4)
+	C, tag := NativeEncrypt(key, NonceRegistration, m)
+	got, err := NativeDecrypt(key, NonceRegistration, C, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range m {
+		if !got[i].Equal(&m[i]) {
+			t.Fatalf("element %d isn't decrypted", i)
+		}
+	}
+
+	var one fr.Element
+	one.SetOne()
+	forged := append([]fr.Element{}, C...)
+	forged[0].Add(&forged[0], &one)
+	other := randomKey(t)
+	for name, err := range map[string]error{
+		"ciphertext": func() error { _, err := NativeDecrypt(key, NonceRegistration, forged, tag); return err }(),
+		"tag":        func() error { _, err := NativeDecrypt(key, NonceRegistration, C, one); return err }(),
+		"nonce":      func() error { _, err := NativeDecrypt(key, NonceTransfer, C, tag); return err }(),
+		"key":        func() error { _, err := NativeDecrypt(other, NonceRegistration, C, tag); return err }(),
+		"length":     func() error { _, err := NativeDecrypt(key, NonceRegistration, C[:3], tag); return err }(),
+	} {
+		if err == nil {
+			t.Errorf("another %s is decrypted", name)
+		}
+	}
+}
+
+func TestCircuit(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	key, m := randomKey(t), randomPlaintext(t, 3)
+	C, tag := NativeEncrypt(key, NonceTransfer, m)
+
+	// the gadgets compute the ciphertext, the tag and the plaintext of the
+	// native functions
+	assignment := newAEADCircuit(len(m), 0)
+	assignment.G_r_b = twistededwards.Point{X: key.X, Y: key.Y}
+	for i := range m {
+		assignment.Plaintext[i], assignment.Ciphertext[i] = m[i], C[i]
+	}
+	assignment.Tag = tag
+	if err := test.IsSolved(newAEADCircuit(len(m), NonceTransfer), assignment, field); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(newAEADCircuit(len(m), NonceRegistration), assignment, field); err == nil {
+		t.Fatal("the ciphertext of another nonce is accepted")
+	}
+
+	// Decrypt asserts the tag
+	decrypt := func(tamper func(c *decryptCircuit)) error {
+		c := &decryptCircuit{G_r_b: assignment.G_r_b, Ciphertext: make([]frontend.Variable, len(C)), Tag: tag}
+		for i := range C {
+			c.Ciphertext[i] = C[i]
+		}
+		tamper(c)
+		return test.IsSolved(&decryptCircuit{Ciphertext: make([]frontend.Variable, len(C)), nonce: NonceTransfer}, c, field)
+	}
+	if err := decrypt(func(*decryptCircuit) {}); err != nil {
+		t.Fatal(err)
+	}
+	var one fr.Element
+	one.SetOne()
+	for name, tamper := range map[string]func(c *decryptCircuit){
+		"tag":        func(c *decryptCircuit) { c.Tag = one },
+		"ciphertext": func(c *decryptCircuit) { c.Ciphertext[2] = one },
+		"key":        func(c *decryptCircuit) { k := randomKey(t); c.G_r_b = twistededwards.Point{X: k.X, Y: k.Y} },
+	} {
+		if err := decrypt(tamper); err == nil {
+			t.Errorf("a ciphertext of another %s is decrypted", name)
+		}
+	}
+}
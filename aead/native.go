@@ -0,0 +1,52 @@
+package aead
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// NativeEncrypt returns the ciphertext and the tag of the plaintext under the
+// key g_r_b and the nonce, as Encrypt does in-circuit
+func NativeEncrypt(G_r_b edwards.PointAffine, nonce int, plaintext []fr.Element) ([]fr.Element, fr.Element) {
+	state := nativeInitialize(G_r_b, nonce)
+	ciphertext := make([]fr.Element, len(plaintext))
+	for i := range plaintext {
+		ciphertext[i].Add(&plaintext[i], &state)
+		state = note.Hash(state, ciphertext[i])
+	}
+	return ciphertext, nativeSqueeze(state, len(plaintext))
+}
+
+// NativeDecrypt returns the plaintext of the ciphertext under the key g_r_b and
+// the nonce, or an error when the tag doesn't authenticate the ciphertext, as
+// Decrypt does in-circuit
+func NativeDecrypt(G_r_b edwards.PointAffine, nonce int, ciphertext []fr.Element, tag fr.Element) ([]fr.Element, error) {
+	state := nativeInitialize(G_r_b, nonce)
+	plaintext := make([]fr.Element, len(ciphertext))
+	for i := range ciphertext {
+		plaintext[i].Sub(&ciphertext[i], &state)
+		state = note.Hash(state, ciphertext[i])
+	}
+	if expected := nativeSqueeze(state, len(ciphertext)); !expected.Equal(&tag) {
+		return nil, fmt.Errorf("the tag doesn't authenticate the ciphertext")
+	}
+	return plaintext, nil
+}
+
+// nativeInitialize returns s_0 = H(g_r_b.X, g_r_b.Y, nonce)
+func nativeInitialize(G_r_b edwards.PointAffine, nonce int) fr.Element {
+	var n fr.Element
+	n.SetUint64(uint64(nonce))
+	return note.Hash(G_r_b.X, G_r_b.Y, n)
+}
+
+// nativeSqueeze returns the tag H(s_n, n)
+func nativeSqueeze(state fr.Element, n int) fr.Element {
+	var length fr.Element
+	length.SetUint64(uint64(n))
+	return note.Hash(state, length)
+}
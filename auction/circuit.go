@@ -0,0 +1,292 @@
+package auction
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/cmp"
+	"github.com/consensys/gnark/std/rangecheck"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/sorting"
+)
+
+func init() {
+	solver.RegisterHint(divHint, volumeHint, lessHint)
+}
+
+// Bid is a bid of the auction with the outcome published for its bidder
+type Bid struct {
+	// limit price
+	Value frontend.Variable
+	// number of units
+	Quantity frontend.Variable
+	// number of units which trade
+	Allocation frontend.Variable
+	// price of each unit paid or received by the bidder, zero when the bid
+	// doesn't trade
+	Price frontend.Variable
+}
+
+// Curves are the demand and supply curves of the auction
+type Curves struct {
+	// prices of the buy bids in decreasing order and of the sell bids in
+	// increasing order
+	Demand []frontend.Variable
+	Supply []frontend.Variable
+	// efficient volume k
+	Volume frontend.Variable
+	// b_k and s_k, zero when k == 0
+	LastBuy  frontend.Variable
+	LastSell frontend.Variable
+	// b_k+1 and s_k+1, zero when the curve has no more units
+	NextBuy  frontend.Variable
+	NextSell frontend.Variable
+	// 1 when b_k+1 (resp. s_k+1) exists
+	HasNextBuy  frontend.Variable
+	HasNextSell frontend.Variable
+}
+
+// Outcome is the outcome of a pricing rule
+type Outcome struct {
+	// number of units traded by the first units of each curve
+	Volume frontend.Variable
+	// BuyPrices[i] is paid for each unit of Demand[i] and SellPrices[j] is
+	// received for each unit of Supply[j], when they trade
+	BuyPrices  []frontend.Variable
+	SellPrices []frontend.Variable
+	// clearing price paid by every buyer and received by every seller, zero
+	// when each bid trades at its own price
+	BuyPrice  frontend.Variable
+	SellPrice frontend.Variable
+}
+
+// VerifyClearing asserts that Volume, the clearing prices BuyPrice and
+// SellPrice, and the allocations and prices of the bids are the clearing of the
+// bids under the rule, as computed by Clear. The clearing prices are zero when
+// no unit trades. The values and the quantities of the bids must fit in
+// note.ValueBits bits.
+//
+// The bids are sorted into the demand and supply curves with their quantity,
+// allocation and price, so that the outcome of the rule, computed on the
+// curves, is checked against the values published for each bidder.
+func VerifyClearing(api frontend.API, rule Rule, Volume, BuyPrice, SellPrice frontend.Variable, Buys, Sells []Bid) error {
+	if len(Buys) == 0 || len(Sells) == 0 {
+		return fmt.Errorf("the auction needs buy and sell bids, got %d and %d", len(Buys), len(Sells))
+	}
+	bits := volumeBits(max(len(Buys), len(Sells)))
+
+	demand, err := sortBids(api, Buys, sorting.Decreasing)
+	if err != nil {
+		return err
+	}
+	supply, err := sortBids(api, Sells, sorting.Increasing)
+	if err != nil {
+		return err
+	}
+	curves, err := newCurves(api, demand, supply, bits)
+	if err != nil {
+		return err
+	}
+
+	outcome, err := rule.Define(api, curves)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(Volume, outcome.Volume)
+	traded := api.Sub(1, api.IsZero(outcome.Volume))
+	api.AssertIsEqual(BuyPrice, api.Mul(traded, outcome.BuyPrice))
+	api.AssertIsEqual(SellPrice, api.Mul(traded, outcome.SellPrice))
+	// the volume traded can't exceed the efficient one, so that the units
+	// which trade are the first ones of both curves
+	rangecheck.New(api).Check(outcome.Volume, bits)
+	api.AssertIsEqual(less(api, curves.Volume, outcome.Volume, bits), 0)
+
+	verifyOutcome(api, outcome.Volume, demand, outcome.BuyPrices, bits)
+	verifyOutcome(api, outcome.Volume, supply, outcome.SellPrices, bits)
+	return nil
+}
+
+// sortBids sorts the bids by value, with their quantity, allocation and price
+func sortBids(api frontend.API, bids []Bid, order sorting.Order) ([]Bid, error) {
+	entries := make([]sorting.Entry, len(bids))
+	for i := range bids {
+		entries[i] = sorting.Entry{
+			Key:     bids[i].Value,
+			Payload: []frontend.Variable{bids[i].Quantity, bids[i].Allocation, bids[i].Price},
+		}
+	}
+	sorted, err := sorting.Sort(api, entries, order, note.ValueBits)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Bid, len(sorted))
+	for i := range sorted {
+		res[i] = Bid{Value: sorted[i].Key, Quantity: sorted[i].Payload[0], Allocation: sorted[i].Payload[1], Price: sorted[i].Payload[2]}
+	}
+	return res, nil
+}
+
+// newCurves computes the efficient volume and the marginal units of the
+// sorted bids, whose total quantities fit in bits bits
+func newCurves(api frontend.API, demand, supply []Bid, bits int) (*Curves, error) {
+	c := &Curves{
+		Demand: make([]frontend.Variable, len(demand)),
+		Supply: make([]frontend.Variable, len(supply)),
+	}
+	for i := range demand {
+		c.Demand[i] = demand[i].Value
+	}
+	for j := range supply {
+		c.Supply[j] = supply[j].Value
+	}
+
+	// k is computed by a hint, and is the efficient volume when it is at most
+	// the quantity of both curves, the k-th units cross and the k+1-th don't,
+	// as b_u >= s_u holds for the first units only
+	inputs := []frontend.Variable{len(demand)}
+	for _, b := range append(append([]Bid{}, demand...), supply...) {
+		inputs = append(inputs, b.Value, b.Quantity)
+	}
+	res, err := api.Compiler().NewHint(volumeHint, 1, inputs...)
+	if err != nil {
+		return nil, err
+	}
+	c.Volume = res[0]
+	rangecheck.New(api).Check(c.Volume, bits)
+
+	c.LastBuy, c.NextBuy, c.HasNextBuy = marginal(api, c.Volume, demand, bits)
+	c.LastSell, c.NextSell, c.HasNextSell = marginal(api, c.Volume, supply, bits)
+
+	comparator := bounded(api)
+	crossing := comparator.IsLessEq(c.LastSell, c.LastBuy)
+	api.AssertIsEqual(api.Mul(api.Sub(1, api.IsZero(c.Volume)), api.Sub(1, crossing)), 0)
+	next := comparator.IsLessEq(c.NextSell, c.NextBuy)
+	api.AssertIsEqual(api.Mul(api.Mul(c.HasNextBuy, c.HasNextSell), next), 0)
+	return c, nil
+}
+
+// marginal returns the prices of the k-th and k+1-th units of the sorted bids,
+// zero when they don't exist, and 1 when the k+1-th unit exists. The k-th
+// unit is the last one when k is the quantity of the curve.
+func marginal(api frontend.API, k frontend.Variable, bids []Bid, bits int) (last, next, hasNext frontend.Variable) {
+	// before == 1 while the units of the first bids are before the k-th one,
+	// upTo == 1 while they are up to the k-th one
+	before := api.Sub(1, api.IsZero(k))
+	var upTo, total frontend.Variable = 1, 0
+	last, next = 0, 0
+	for i := range bids {
+		total = api.Add(total, bids[i].Quantity)
+		isBefore := less(api, total, k, bits)
+		isUpTo := api.Sub(1, less(api, k, total, bits))
+		last = api.Add(last, api.Mul(api.Sub(before, isBefore), bids[i].Value))
+		next = api.Add(next, api.Mul(api.Sub(upTo, isUpTo), bids[i].Value))
+		before, upTo = isBefore, isUpTo
+	}
+	// k is at most the quantity of the curve
+	api.AssertIsEqual(before, 0)
+	// the k+1-th unit exists unless every unit is up to the k-th one
+	return last, next, api.Sub(1, upTo)
+}
+
+// verifyOutcome asserts that the first volume units of the curve trade at the
+// given prices and the other ones don't
+func verifyOutcome(api frontend.API, volume frontend.Variable, curve []Bid, prices []frontend.Variable, bits int) {
+	var total frontend.Variable = 0
+	for i := range curve {
+		// the bid trades min(max(volume - total, 0), quantity) units
+		started := less(api, total, volume, bits)
+		next := api.Add(total, curve[i].Quantity)
+		full := api.Sub(1, less(api, volume, next, bits))
+		allocation := api.Add(api.Mul(full, curve[i].Quantity), api.Mul(api.Sub(started, full), api.Sub(volume, total)))
+		api.AssertIsEqual(curve[i].Allocation, allocation)
+		matched := api.Sub(1, api.IsZero(allocation))
+		api.AssertIsEqual(curve[i].Price, api.Mul(matched, prices[i]))
+		total = next
+	}
+}
+
+// volumeBits returns the size of the total quantity of n bids
+func volumeBits(n int) int {
+	return note.ValueBits + bits.Len(uint(n))
+}
+
+// less returns 1 when a < b, a and b fitting in bits bits
+func less(api frontend.API, a, b frontend.Variable, bits int) frontend.Variable {
+	res, err := api.Compiler().NewHint(lessHint, 1, a, b)
+	if err != nil {
+		panic(err)
+	}
+	isLess := res[0]
+	api.AssertIsBoolean(isLess)
+	// b - a - 1 fits in bits bits when a < b, and a - b otherwise
+	rangecheck.New(api).Check(api.Select(isLess, api.Sub(b, a, 1), api.Sub(a, b)), bits)
+	return isLess
+}
+
+// bounded returns a comparator of bids
+func bounded(api frontend.API) *cmp.BoundedComparator {
+	return cmp.NewBoundedComparator(api, new(big.Int).Lsh(big.NewInt(1), uint(note.ValueBits)), false)
+}
+
+// divFloor returns ⌊x / d⌋, which must fit in the size of the bids
+func divFloor(api frontend.API, x frontend.Variable, d uint64) (frontend.Variable, error) {
+	res, err := api.Compiler().NewHint(divHint, 2, x, d)
+	if err != nil {
+		return nil, err
+	}
+	q, r := res[0], res[1]
+	// x == q·d + r with 0 <= r < d, q being bounded so that q·d + r doesn't
+	// wrap around the modulus
+	api.AssertIsEqual(x, api.Add(api.Mul(q, d), r))
+	rc := rangecheck.New(api)
+	rc.Check(q, note.ValueBits)
+	bits := new(big.Int).SetUint64(d).BitLen()
+	rc.Check(r, bits)
+	rc.Check(api.Sub(d-1, r), bits)
+	return q, nil
+}
+
+// divHint returns the quotient and the remainder of the euclidean division of
+// inputs[0] by inputs[1]
+func divHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != 2 || len(outputs) != 2 || inputs[1].Sign() == 0 {
+		return fmt.Errorf("divHint expects a dividend and a non-zero divisor")
+	}
+	outputs[0].DivMod(inputs[0], inputs[1], outputs[1])
+	return nil
+}
+
+// volumeHint returns the efficient volume of the sorted bids, given as the
+// number of buy bids followed by the value and the quantity of each bid
+func volumeHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) == 0 || len(outputs) != 1 || len(inputs)%2 != 1 {
+		return fmt.Errorf("volumeHint expects the number of buy bids and the bids")
+	}
+	bids := make([]NativeBid, (len(inputs)-1)/2)
+	for i := range bids {
+		bids[i].Value.SetBigInt(inputs[1+2*i])
+		bids[i].Quantity.SetBigInt(inputs[2+2*i])
+	}
+	n := int(inputs[0].Int64())
+	if n < 0 || n > len(bids) {
+		return fmt.Errorf("volumeHint got %d buy bids out of %d", n, len(bids))
+	}
+	outputs[0].Set(efficientVolume(bids[:n], bids[n:]))
+	return nil
+}
+
+// lessHint returns 1 when inputs[0] < inputs[1]
+func lessHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != 2 || len(outputs) != 1 {
+		return fmt.Errorf("lessHint expects two values")
+	}
+	outputs[0].SetUint64(0)
+	if inputs[0].Cmp(inputs[1]) < 0 {
+		outputs[0].SetUint64(1)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Package auction implements the clearing of the double auction computed by
+// the auctioneer in ProofF. Each bid offers a quantity at a limit price, and
+// counts as that many units at its price: the buy bids sorted in decreasing
+// order of price form the demand curve b_1 >= b_2 >= ... of the units, the
+// sell bids sorted in increasing order the supply curve s_1 <= s_2 <= ..., and
+// the efficient volume k is the largest number of units such that b_k >= s_k.
+// The curves of the participants, one bid per step, are thus aggregated into
+// the curves of the market.
+//
+// A pricing Rule then decides how many of the first units of each curve trade,
+// and at which prices: uniform price, pay-as-bid, k-double auction or McAfee's
+// trade reduction. Clear computes the clearing natively and VerifyClearing
+// checks it in-circuit.
+package auction
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+)
+
+// NativeBid is a bid of the auction
+type NativeBid struct {
+	// limit price
+	Value fr.Element
+	// number of units
+	Quantity fr.Element
+}
+
+// NativeCurves are the demand and supply curves of the auction
+type NativeCurves struct {
+	// prices of the buy bids in decreasing order and of the sell bids in
+	// increasing order
+	Demand []fr.Element
+	Supply []fr.Element
+	// efficient volume k
+	Volume fr.Element
+	// b_k and s_k, zero when k == 0
+	LastBuy  fr.Element
+	LastSell fr.Element
+	// b_k+1 and s_k+1, zero when the curve has no more units
+	NextBuy  fr.Element
+	NextSell fr.Element
+	// true when b_k+1 (resp. s_k+1) exists
+	HasNextBuy  bool
+	HasNextSell bool
+}
+
+// NativeOutcome is the outcome of a pricing rule
+type NativeOutcome struct {
+	// number of units traded by the first units of each curve
+	Volume fr.Element
+	// BuyPrices[i] is paid for each unit of Demand[i] and SellPrices[j] is
+	// received for each unit of Supply[j], when they trade
+	BuyPrices  []fr.Element
+	SellPrices []fr.Element
+	// clearing price paid by every buyer and received by every seller, zero
+	// when each bid trades at its own price
+	BuyPrice  fr.Element
+	SellPrice fr.Element
+}
+
+// Clearing is the outcome of the auction, in the order of the bids
+type Clearing struct {
+	// number of units traded
+	Volume fr.Element
+	// Buys[i] (resp. Sells[j]) is the number of units of the i-th buy (resp.
+	// j-th sell) bid which trade
+	Buys  []fr.Element
+	Sells []fr.Element
+	// price paid for each unit by each buyer and received by each seller, zero
+	// when the bid doesn't trade
+	BuyPrices  []fr.Element
+	SellPrices []fr.Element
+	// clearing price paid by the buyers and received by the sellers, zero when
+	// no unit trades or each bid trades at its own price
+	BuyPrice  fr.Element
+	SellPrice fr.Element
+}
+
+// Clear computes the clearing of the buy and sell bids under the rule
+func Clear(buys, sells []NativeBid, rule Rule) (Clearing, error) {
+	if len(buys) == 0 || len(sells) == 0 {
+		return Clearing{}, fmt.Errorf("the auction needs buy and sell bids, got %d and %d", len(buys), len(sells))
+	}
+
+	// demand and supply curves, as indices of the bids
+	demand := order(buys, func(a, b *fr.Element) bool { return a.Cmp(b) > 0 })
+	supply := order(sells, func(a, b *fr.Element) bool { return a.Cmp(b) < 0 })
+	demandBids, supplyBids := sorted(buys, demand), sorted(sells, supply)
+	curves := newNativeCurves(demandBids, supplyBids)
+
+	outcome := rule.Native(&curves)
+	if outcome.Volume.Cmp(&curves.Volume) > 0 {
+		return Clearing{}, fmt.Errorf("%s trades %s units, more than the efficient volume %s", rule.Name(), outcome.Volume.String(), curves.Volume.String())
+	}
+	c := Clearing{
+		Volume:     outcome.Volume,
+		Buys:       make([]fr.Element, len(buys)),
+		Sells:      make([]fr.Element, len(sells)),
+		BuyPrices:  make([]fr.Element, len(buys)),
+		SellPrices: make([]fr.Element, len(sells)),
+	}
+	if !c.Volume.IsZero() {
+		c.BuyPrice, c.SellPrice = outcome.BuyPrice, outcome.SellPrice
+	}
+	volume := outcome.Volume.BigInt(new(big.Int))
+	for i, a := range allocations(volume, demandBids) {
+		if !a.IsZero() {
+			c.Buys[demand[i]] = a
+			c.BuyPrices[demand[i]] = outcome.BuyPrices[i]
+		}
+	}
+	for j, a := range allocations(volume, supplyBids) {
+		if !a.IsZero() {
+			c.Sells[supply[j]] = a
+			c.SellPrices[supply[j]] = outcome.SellPrices[j]
+		}
+	}
+	return c, nil
+}
+
+// newNativeCurves computes the efficient volume and the marginal units of the
+// sorted bids
+func newNativeCurves(demand, supply []NativeBid) NativeCurves {
+	c := NativeCurves{
+		Demand: make([]fr.Element, len(demand)),
+		Supply: make([]fr.Element, len(supply)),
+	}
+	for i := range demand {
+		c.Demand[i] = demand[i].Value
+	}
+	for j := range supply {
+		c.Supply[j] = supply[j].Value
+	}
+	k := efficientVolume(demand, supply)
+	c.Volume.SetBigInt(k)
+	next := new(big.Int).Add(k, big.NewInt(1))
+	if k.Sign() > 0 {
+		c.LastBuy, _ = unit(demand, k)
+		c.LastSell, _ = unit(supply, k)
+	}
+	c.NextBuy, c.HasNextBuy = unit(demand, next)
+	c.NextSell, c.HasNextSell = unit(supply, next)
+	return c
+}
+
+// efficientVolume returns the largest number of units u such that b_u >= s_u.
+// As b_u+1 == b_u and s_u+1 == s_u unless a bid ends at u, it is either zero
+// or the total quantity of some first bids of a curve.
+func efficientVolume(demand, supply []NativeBid) *big.Int {
+	k := new(big.Int)
+	for _, u := range append(cumulative(demand)[1:], cumulative(supply)[1:]...) {
+		b, okB := unit(demand, u)
+		s, okS := unit(supply, u)
+		if okB && okS && b.Cmp(&s) >= 0 && u.Cmp(k) > 0 {
+			k.Set(u)
+		}
+	}
+	return k
+}
+
+// unit returns the price of the u-th unit of the sorted bids, and false when
+// they have less than u units
+func unit(bids []NativeBid, u *big.Int) (fr.Element, bool) {
+	c := cumulative(bids)
+	i := sort.Search(len(bids), func(i int) bool { return c[i+1].Cmp(u) >= 0 })
+	if i == len(bids) {
+		return fr.Element{}, false
+	}
+	return bids[i].Value, true
+}
+
+// allocations returns the number of units of each sorted bid among the first
+// volume units of the curve
+func allocations(volume *big.Int, bids []NativeBid) []fr.Element {
+	res := make([]fr.Element, len(bids))
+	c := cumulative(bids)
+	for i := range bids {
+		// min(max(volume - c_i, 0), quantity)
+		a := new(big.Int).Sub(volume, c[i])
+		if a.Sign() < 0 {
+			a.SetInt64(0)
+		}
+		if q := bids[i].Quantity.BigInt(new(big.Int)); a.Cmp(q) > 0 {
+			a = q
+		}
+		res[i].SetBigInt(a)
+	}
+	return res
+}
+
+// cumulative returns the total quantities of the 0, 1, ..., n first bids
+func cumulative(bids []NativeBid) []*big.Int {
+	res := make([]*big.Int, len(bids)+1)
+	res[0] = new(big.Int)
+	for i := range bids {
+		res[i+1] = new(big.Int).Add(res[i], bids[i].Quantity.BigInt(new(big.Int)))
+	}
+	return res
+}
+
+// order returns the indices of the bids sorted by price, ties keeping the
+// order of the bids as sorting.Sort does
+func order(bids []NativeBid, less func(a, b *fr.Element) bool) []int {
+	indices := make([]int, len(bids))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return less(&bids[indices[a]].Value, &bids[indices[b]].Value)
+	})
+	return indices
+}
+
+// sorted returns the bids in the order of the indices
+func sorted(bids []NativeBid, indices []int) []NativeBid {
+	res := make([]NativeBid, len(indices))
+	for i, k := range indices {
+		res[i] = bids[k]
+	}
+	return res
+}
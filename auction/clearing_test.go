@@ -0,0 +1,158 @@
+package auction
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+var rules = []string{"uniform", "pay-as-bid", "mcafee", "k-double:1/2"}
+
+type clearingCircuit struct {
+	Volume    frontend.Variable `gnark:",public"`
+	BuyPrice  frontend.Variable `gnark:",public"`
+	SellPrice frontend.Variable `gnark:",public"`
+	Buys      []Bid
+	Sells     []Bid
+	rule      Rule
+}
+
+func (c *clearingCircuit) Define(api frontend.API) error {
+	return VerifyClearing(api, c.rule, c.Volume, c.BuyPrice, c.SellPrice, c.Buys, c.Sells)
+}
+
+func newClearingCircuit(buys, sells int, rule Rule) *clearingCircuit {
+	return &clearingCircuit{Buys: make([]Bid, buys), Sells: make([]Bid, sells), rule: rule}
+}
+
+// assignClearing assigns the bids and the clearing computed by Clear
+func assignClearing(t *testing.T, buys, sells []NativeBid, rule Rule) *clearingCircuit {
+	t.Helper()
+	c, err := Clear(buys, sells, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := newClearingCircuit(len(buys), len(sells), rule)
+	res.Volume, res.BuyPrice, res.SellPrice = c.Volume, c.BuyPrice, c.SellPrice
+	for i := range buys {
+		res.Buys[i] = Bid{Value: buys[i].Value, Quantity: buys[i].Quantity, Allocation: c.Buys[i], Price: c.BuyPrices[i]}
+	}
+	for j := range sells {
+		res.Sells[j] = Bid{Value: sells[j].Value, Quantity: sells[j].Quantity, Allocation: c.Sells[j], Price: c.SellPrices[j]}
+	}
+	return res
+}
+
+func nativeBids(values, quantities []uint64) []NativeBid {
+	res := make([]NativeBid, len(values))
+	for i := range res {
+		res[i].Value.SetUint64(values[i])
+		res[i].Quantity.SetUint64(quantities[i])
+	}
+	return res
+}
+
+// randomBids draws n bids of prices and quantities below 20, so that the
+// curves cross
+func randomBids(n int) []NativeBid {
+	values, quantities := make([]uint64, n), make([]uint64, n)
+	for i := range values {
+		values[i], quantities[i] = rand.Uint64N(20), rand.Uint64N(20)
+	}
+	return nativeBids(values, quantities)
+}
+
+func TestClearing(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	for _, name := range rules {
+		rule, err := ParseRule(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 4 {
+			buys, sells := randomBids(4), randomBids(3)
+			if err := test.IsSolved(newClearingCircuit(4, 3, rule), assignClearing(t, buys, sells, rule), field); err != nil {
+				t.Fatalf("%s: the clearing computed natively is refused: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestClearingTampered(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	// two buyers bid the same price for more units than supplied, so that the
+	// marginal unit goes to the first one
+	buys := nativeBids([]uint64{10, 12, 10}, []uint64{3, 2, 3})
+	sells := nativeBids([]uint64{2, 4}, []uint64{4, 2})
+	var one fr.Element
+	one.SetOne()
+
+	for _, name := range rules {
+		rule, err := ParseRule(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.IsSolved(newClearingCircuit(3, 2, rule), assignClearing(t, buys, sells, rule), field); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for tamper, f := range map[string]func(c *clearingCircuit){
+			"marginal unit to the later tied bid": func(c *clearingCircuit) {
+				c.Buys[0].Allocation, c.Buys[2].Allocation = c.Buys[2].Allocation, c.Buys[0].Allocation
+				c.Buys[0].Price, c.Buys[2].Price = c.Buys[2].Price, c.Buys[0].Price
+			},
+			"another volume": func(c *clearingCircuit) {
+				v := c.Volume.(fr.Element)
+				c.Volume = *v.Sub(&v, &one)
+			},
+			"another price": func(c *clearingCircuit) {
+				p := c.Sells[0].Price.(fr.Element)
+				c.Sells[0].Price = *p.Add(&p, &one)
+			},
+			"another published buy price": func(c *clearingCircuit) {
+				p := c.BuyPrice.(fr.Element)
+				c.BuyPrice = *p.Add(&p, &one)
+			},
+			"another published sell price": func(c *clearingCircuit) {
+				p := c.SellPrice.(fr.Element)
+				c.SellPrice = *p.Sub(&p, &one)
+			},
+		} {
+			c := assignClearing(t, buys, sells, rule)
+			f(c)
+			if err := test.IsSolved(newClearingCircuit(3, 2, rule), c, field); err == nil {
+				t.Errorf("%s: %s is accepted", name, tamper)
+			}
+		}
+	}
+}
+
+func TestClearingPrices(t *testing.T) {
+	// the demand curve is 12, 12, 10, ... (6 units at 10) and the supply curve
+	// 2, 2, 2, 2, 4, 4, so that k == 6, b_k == 10 and s_k == 4
+	buys := nativeBids([]uint64{10, 12, 10}, []uint64{3, 2, 3})
+	sells := nativeBids([]uint64{2, 4}, []uint64{4, 2})
+	for name, expected := range map[string][3]uint64{
+		"uniform":      {6, 10, 10},
+		"pay-as-bid":   {6, 0, 0},
+		"k-double:1/2": {6, 7, 7},
+		// s_k+1 doesn't exist, so that the trade is reduced
+		"mcafee": {5, 10, 4},
+	} {
+		rule, err := ParseRule(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := Clear(buys, sells, rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := [3]uint64{c.Volume.Uint64(), c.BuyPrice.Uint64(), c.SellPrice.Uint64()}
+		if got != expected {
+			t.Errorf("%s: got the volume and the prices %v, expected %v", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package auction
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Rule is a pricing rule: given the curves, it decides how many units trade
+// and at which prices, natively and in-circuit. A rule pricing every unit of
+// a side at the same price publishes it as the clearing price of the side.
+type Rule interface {
+	// Name identifies the rule, as parsed by ParseRule
+	Name() string
+	// Native computes the outcome of the rule
+	Native(c *NativeCurves) NativeOutcome
+	// Define computes the outcome of the rule in-circuit
+	Define(api frontend.API, c *Curves) (Outcome, error)
+}
+
+// ParseRule returns the rule of the given name: uniform, pay-as-bid, mcafee or
+// k-double:<num>/<den> for a k-double auction with k = num/den
+func ParseRule(name string) (Rule, error) {
+	switch name {
+	case "uniform":
+		return Uniform{}, nil
+	case "pay-as-bid":
+		return PayAsBid{}, nil
+	case "mcafee":
+		return McAfee{}, nil
+	}
+	var k KDouble
+	if _, err := fmt.Sscanf(name, "k-double:%d/%d", &k.Num, &k.Den); err == nil && k.Name() == name {
+		return k, k.check()
+	}
+	return nil, fmt.Errorf("unknown pricing rule %q, expected uniform, pay-as-bid, mcafee or k-double:<num>/<den>", name)
+}
+
+// Uniform trades the k efficient units at the lowest price at which they are
+// both demanded and supplied, max(s_k, b_k+1)
+type Uniform struct{}
+
+func (Uniform) Name() string {
+	return "uniform"
+}
+
+func (Uniform) Native(c *NativeCurves) NativeOutcome {
+	o := newNativeOutcome(c, c.Volume)
+	if c.Volume.IsZero() {
+		return o
+	}
+	price := c.LastSell
+	if c.HasNextBuy && c.NextBuy.Cmp(&price) > 0 {
+		price = c.NextBuy
+	}
+	o.fill(price, price)
+	return o
+}
+
+func (Uniform) Define(api frontend.API, c *Curves) (Outcome, error) {
+	// NextBuy is zero when there is no b_k+1
+	price := api.Select(bounded(api).IsLess(c.LastSell, c.NextBuy), c.NextBuy, c.LastSell)
+	return newOutcome(c, c.Volume, price, price), nil
+}
+
+// PayAsBid trades the k efficient units, each buyer paying its bid and each
+// seller receiving its bid for each unit
+type PayAsBid struct{}
+
+func (PayAsBid) Name() string {
+	return "pay-as-bid"
+}
+
+func (PayAsBid) Native(c *NativeCurves) NativeOutcome {
+	o := newNativeOutcome(c, c.Volume)
+	copy(o.BuyPrices, c.Demand)
+	copy(o.SellPrices, c.Supply)
+	return o
+}
+
+func (PayAsBid) Define(api frontend.API, c *Curves) (Outcome, error) {
+	return Outcome{
+		Volume:     c.Volume,
+		BuyPrices:  c.Demand,
+		SellPrices: c.Supply,
+		BuyPrice:   0,
+		SellPrice:  0,
+	}, nil
+}
+
+// KDouble is the k-double auction: the k efficient units trade at
+// ⌊k·b_k + (1-k)·s_k⌋, k being Num/Den
+type KDouble struct {
+	Num, Den uint64
+}
+
+func (r KDouble) Name() string {
+	return fmt.Sprintf("k-double:%d/%d", r.Num, r.Den)
+}
+
+// check ensures 0 <= k <= 1
+func (r KDouble) check() error {
+	if r.Den == 0 || r.Num > r.Den {
+		return fmt.Errorf("invalid k-double auction %d/%d, k must be in [0, 1]", r.Num, r.Den)
+	}
+	return nil
+}
+
+func (r KDouble) Native(c *NativeCurves) NativeOutcome {
+	o := newNativeOutcome(c, c.Volume)
+	if c.Volume.IsZero() {
+		return o
+	}
+	b := c.LastBuy.BigInt(new(big.Int))
+	s := c.LastSell.BigInt(new(big.Int))
+	x := new(big.Int).Mul(b, new(big.Int).SetUint64(r.Num))
+	x.Add(x, s.Mul(s, new(big.Int).SetUint64(r.Den-r.Num)))
+	x.Div(x, new(big.Int).SetUint64(r.Den))
+	var price fr.Element
+	price.SetBigInt(x)
+	o.fill(price, price)
+	return o
+}
+
+func (r KDouble) Define(api frontend.API, c *Curves) (Outcome, error) {
+	if err := r.check(); err != nil {
+		return Outcome{}, err
+	}
+	x := api.Add(api.Mul(c.LastBuy, r.Num), api.Mul(c.LastSell, r.Den-r.Num))
+	price, err := divFloor(api, x, r.Den)
+	if err != nil {
+		return Outcome{}, err
+	}
+	return newOutcome(c, c.Volume, price, price), nil
+}
+
+// McAfee is McAfee's trade reduction: when p = ⌊(b_k+1 + s_k+1)/2⌋ is between
+// s_k and b_k, the k efficient units trade at p. Otherwise only k-1 units
+// trade, buyers paying b_k and sellers receiving s_k.
+type McAfee struct{}
+
+func (McAfee) Name() string {
+	return "mcafee"
+}
+
+func (McAfee) Native(c *NativeCurves) NativeOutcome {
+	k := c.Volume
+	if k.IsZero() {
+		return newNativeOutcome(c, k)
+	}
+	if c.HasNextBuy && c.HasNextSell {
+		b := c.NextBuy.BigInt(new(big.Int))
+		s := c.NextSell.BigInt(new(big.Int))
+		var price fr.Element
+		price.SetBigInt(b.Add(b, s).Rsh(b, 1))
+		if c.LastSell.Cmp(&price) <= 0 && price.Cmp(&c.LastBuy) <= 0 {
+			o := newNativeOutcome(c, k)
+			o.fill(price, price)
+			return o
+		}
+	}
+	var reduced fr.Element
+	reduced.Sub(&k, new(fr.Element).SetOne())
+	o := newNativeOutcome(c, reduced)
+	o.fill(c.LastBuy, c.LastSell)
+	return o
+}
+
+func (McAfee) Define(api frontend.API, c *Curves) (Outcome, error) {
+	price, err := divFloor(api, api.Add(c.NextBuy, c.NextSell), 2)
+	if err != nil {
+		return Outcome{}, err
+	}
+	comparator := bounded(api)
+	// the k units trade at price
+	all := api.Mul(api.Mul(c.HasNextBuy, c.HasNextSell), api.Mul(comparator.IsLessEq(c.LastSell, price), comparator.IsLessEq(price, c.LastBuy)))
+	// k-1 units trade otherwise, unless k == 0
+	reduced := api.Mul(api.Sub(1, all), api.Sub(1, api.IsZero(c.Volume)))
+	volume := api.Sub(c.Volume, reduced)
+	return newOutcome(c, volume, api.Select(all, price, c.LastBuy), api.Select(all, price, c.LastSell)), nil
+}
+
+// newNativeOutcome returns the outcome of a rule trading volume units, whose
+// prices are set by the rule
+func newNativeOutcome(c *NativeCurves, volume fr.Element) NativeOutcome {
+	return NativeOutcome{
+		Volume:     volume,
+		BuyPrices:  make([]fr.Element, len(c.Demand)),
+		SellPrices: make([]fr.Element, len(c.Supply)),
+	}
+}
+
+// fill sets a single price for the buyers and one for the sellers
+func (o *NativeOutcome) fill(buy, sell fr.Element) {
+	o.BuyPrice, o.SellPrice = buy, sell
+	for i := range o.BuyPrices {
+		o.BuyPrices[i] = buy
+	}
+	for j := range o.SellPrices {
+		o.SellPrices[j] = sell
+	}
+}
+
+// newOutcome returns the outcome of a rule trading volume units with a single
+// price for the buyers and one for the sellers
+func newOutcome(c *Curves, volume, buy, sell frontend.Variable) Outcome {
+	o := Outcome{
+		Volume:     volume,
+		BuyPrices:  make([]frontend.Variable, len(c.Demand)),
+		SellPrices: make([]frontend.Variable, len(c.Supply)),
+		BuyPrice:   buy,
+		SellPrice:  sell,
+	}
+	for i := range o.BuyPrices {
+		o.BuyPrices[i] = buy
+	}
+	for j := range o.SellPrices {
+		o.SellPrices[j] = sell
+	}
+	return o
+}
@@ -0,0 +1,175 @@
+// Package auctioneer holds the secret key b of the auctioneer, whose public key
+// is g_b == g^b. A participant registering a bid encrypts (nk_in, pk_out, b)
+// in ProofReg under g_r_b == g_b^r (see package aead); the auctioneer computes
+// the same point as g_r^b from the public g_r, decrypts the three fields and
+// gathers the bids of a round into the witness.Round from which ProofF is
+// filled.
+//
+// The encrypted fields don't open the notes: along with its registration, the
+// participant sends the auctioneer the opening of N_in and the randomness of
+// N_out (see Registration). The opening is checked against the public Cm_in
+// before the bid is accepted. N_out holds the value of N_in until ProofF
+// settles the payment of the participant (see witness.Secrets.Settle).
+//
+// The key g_r_b of each participant is public in ProofF, and proven to be
+// g_r^b with a DLEQ proof (see Prove). The secret key may also be shared
+// between the members of a committee (see Committee), none of which can read
+// the bids alone.
+package auctioneer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/auction"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// Auctioneer holds the keys of the auctioneer
+type Auctioneer struct {
+	// secret key b
+	B fr.Element
+	// public generator g and key g_b == g^b
+	G   edwards.PointAffine
+	G_b edwards.PointAffine
+}
+
+// Registration is what the auctioneer receives for a bid: the public values of
+// ProofReg, and the opening of the notes sent privately by the participant
+type Registration struct {
+	Cm_in      fr.Element
+	Nk_in_enc  fr.Element
+	Pk_out_enc fr.Element
+	B_enc      [bid.Steps]fr.Element
+	Tag        fr.Element
+	G_r        edwards.PointAffine
+
+	// opening of N_in, only T, Pk, Rho and R are used
+	N_in note.NativeNote
+	// randomness of N_out, whose pk is encrypted, rho derived and value
+	// settled by ProofF
+	R_out fr.Element
+}
+
+// New returns an auctioneer of a random secret key, for the base point g of
+// the twisted Edwards curve of BLS12-377
+func New() (*Auctioneer, error) {
+	var a Auctioneer
+	var err error
+	a.B, a.G, a.G_b, err = witness.RandomKey()
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// NewRegistration returns the registration sent to the auctioneer by the
+// participant of the secrets
+func NewRegistration(s *witness.Secrets) (Registration, error) {
+	inst, err := s.Instance()
+	if err != nil {
+		return Registration{}, err
+	}
+	return Registration{
+		Cm_in:      inst.Cm_in,
+		Nk_in_enc:  inst.Nk_in_enc,
+		Pk_out_enc: inst.Pk_out_enc,
+		B_enc:      inst.B_enc,
+		Tag:        inst.Tag,
+		G_r:        inst.G_r,
+
+		// the spending key stays with the participant
+		N_in:  note.NativeNote{T: s.N_in.T, Pk: s.N_in.Pk, Rho: s.N_in.Rho, R: s.N_in.R, Cm: s.N_in.Cm},
+		R_out: s.N_out.R,
+	}, nil
+}
+
+// Decrypt decrypts the registration and checks the opening of N_in against
+// Cm_in. It returns the secrets of the participant known to the auctioneer:
+// N_in without its spending key but with its nullifier key, N_out, the bid,
+// and g_r and g_r_b in place of r.
+func (a *Auctioneer) Decrypt(reg *Registration) (witness.Secrets, error) {
+	if !note.InSubgroup(&reg.G_r) {
+		return witness.Secrets{}, fmt.Errorf("g_r must be a point of the prime order subgroup of the twisted Edwards curve of BLS12-377")
+	}
+	// g_r_b == g_r^b
+	var G_r_b edwards.PointAffine
+	G_r_b.ScalarMultiplication(&reg.G_r, a.B.BigInt(new(big.Int)))
+	return open(reg, G_r_b, a.G, a.G_b)
+}
+
+// Prove returns the proof that the key g_r_b of the registration, public in
+// ProofF, is g_r^b: g_b and g_r_b are raised to the same secret key
+func (a *Auctioneer) Prove(reg *Registration) (DLEQ, error) {
+	b := a.B.BigInt(new(big.Int))
+	var G_r_b edwards.PointAffine
+	G_r_b.ScalarMultiplication(&reg.G_r, b)
+	return prove(b, &a.G, &a.G_b, &reg.G_r, &G_r_b)
+}
+
+// Round decrypts the registrations of a round cleared under the rule, and
+// returns the bids of the buyers and of the sellers, in the order of the
+// registrations, for the witness builder of ProofF
+func (a *Auctioneer) Round(rule string, regs []Registration) (witness.Round, error) {
+	return round(rule, len(regs), func(k int) (witness.Secrets, error) {
+		return a.Decrypt(&regs[k])
+	})
+}
+
+// open decrypts nk_in, pk_out and the steps of the bid of the registration
+// under g_r_b, and checks the opening of N_in
+func open(reg *Registration, G_r_b, G, G_b edwards.PointAffine) (witness.Secrets, error) {
+	var s witness.Secrets
+	ciphertext := append([]fr.Element{reg.Nk_in_enc, reg.Pk_out_enc}, reg.B_enc[:]...)
+	plaintext, err := aead.NativeDecrypt(G_r_b, aead.NonceRegistration, ciphertext, reg.Tag)
+	if err != nil {
+		return s, fmt.Errorf("the registration isn't encrypted for the auctioneer: %w", err)
+	}
+	s.Nk_in = plaintext[0]
+	Pk_out := plaintext[1]
+	var b [bid.Steps]fr.Element
+	copy(b[:], plaintext[2:])
+	if s.Bid, err = bid.NativeUnpack(b); err != nil {
+		return s, err
+	}
+
+	// the opening of N_in must match the commitment registered
+	if cm := note.NativeCommitment(reg.N_in.T, reg.N_in.R, reg.N_in.Rho, reg.N_in.Pk); !cm.Equal(&reg.Cm_in) {
+		return s, fmt.Errorf("the opening of N_in doesn't match Cm_in")
+	}
+	s.N_in = note.NativeNote{T: reg.N_in.T, Pk: reg.N_in.Pk, Rho: reg.N_in.Rho, R: reg.N_in.R, Cm: reg.Cm_in}
+	s.N_out = note.NativeNote{T: reg.N_in.T, Pk: Pk_out, R: reg.R_out}
+	s.DeriveOut()
+	s.G_r, s.G_r_b, s.G, s.G_b = reg.G_r, G_r_b, G, G_b
+	return s, nil
+}
+
+// round decrypts the n registrations of a round cleared under the rule, and
+// sorts them into buyers and sellers. A bid of another side is an error.
+func round(rule string, n int, decrypt func(k int) (witness.Secrets, error)) (witness.Round, error) {
+	r := witness.Round{Rule: rule}
+	if _, err := auction.ParseRule(rule); err != nil {
+		return r, err
+	}
+	for k := 0; k < n; k++ {
+		s, err := decrypt(k)
+		if err != nil {
+			return r, fmt.Errorf("registration %d: %w", k, err)
+		}
+		switch side := s.Bid.Side; {
+		case side.IsUint64() && side.Uint64() == bid.Buy:
+			r.Buyers = append(r.Buyers, s)
+		case side.IsUint64() && side.Uint64() == bid.Sell:
+			r.Sellers = append(r.Sellers, s)
+		default:
+			return r, fmt.Errorf("registration %d: unknown side %s", k, side.String())
+		}
+	}
+	return r, nil
+}
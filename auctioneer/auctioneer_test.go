@@ -0,0 +1,123 @@
+package auctioneer
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/auction"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooff"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// newRound returns the registrations of a random round encrypted to the
+// auctioneer, with the secrets of the participants
+func newRound(t *testing.T, a *Auctioneer, buyers, sellers int, rule string) (witness.Round, []Registration) {
+	t.Helper()
+	r, err := witness.RandomRound(buyers, sellers, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var regs []Registration
+	for _, side := range [][]witness.Secrets{r.Buyers, r.Sellers} {
+		for i := range side {
+			side[i].G, side[i].G_b = a.G, a.G_b
+			reg, err := NewRegistration(&side[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			regs = append(regs, reg)
+		}
+	}
+	return r, regs
+}
+
+func TestRound(t *testing.T) {
+	a, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, regs := newRound(t, a, 2, 2, "uniform")
+	// the registrations arrive in any order
+	regs[0], regs[3] = regs[3], regs[0]
+
+	r, err := a.Round("uniform", regs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Buyers) != 2 || len(r.Sellers) != 2 {
+		t.Fatalf("got %d buyers and %d sellers, expected 2 and 2", len(r.Buyers), len(r.Sellers))
+	}
+	for k, pair := range [][2]*witness.Secrets{
+		{&r.Buyers[0], &secrets.Buyers[1]}, {&r.Buyers[1], &secrets.Buyers[0]},
+		{&r.Sellers[0], &secrets.Sellers[1]}, {&r.Sellers[1], &secrets.Sellers[0]},
+	} {
+		if pair[0].Bid != pair[1].Bid || pair[0].N_out.Cm != pair[1].N_out.Cm {
+			t.Fatalf("participant %d decrypted isn't the one registered", k)
+		}
+	}
+
+	// the round decrypted by the auctioneer fills ProofF
+	assignment, err := r.ProofF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, _ := auction.ParseRule("uniform")
+	if err := test.IsSolved(prooff.NewCircuit(2, 2, rule), assignment, ecc.BLS12_377.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	a, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, regs := newRound(t, a, 1, 1, "uniform")
+	if _, err := a.Decrypt(&regs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Decrypt(&regs[0]); err == nil {
+		t.Fatal("the registration is decrypted with another key")
+	}
+
+	for name, tamper := range map[string]func(reg *Registration){
+		"ciphertext":      func(reg *Registration) { reg.B_enc[1].SetOne() },
+		"tag":             func(reg *Registration) { reg.Tag.SetOne() },
+		"opening of N_in": func(reg *Registration) { reg.N_in.R.SetOne() },
+		"g_r":             func(reg *Registration) { reg.G_r = a.G },
+		"identity g_r": func(reg *Registration) {
+			reg.G_r.X.SetZero()
+			reg.G_r.Y.SetOne()
+		},
+	} {
+		reg := regs[0]
+		tamper(&reg)
+		if _, err := a.Decrypt(&reg); err == nil {
+			t.Errorf("tampered %s: the registration is decrypted", name)
+		}
+	}
+}
+
+func TestRoundUnknownSide(t *testing.T) {
+	a, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, regs := newRound(t, a, 1, 1, "uniform")
+	for _, side := range []uint64{2, 3} {
+		_, err := round("uniform", len(regs), func(k int) (witness.Secrets, error) {
+			s, err := a.Decrypt(&regs[k])
+			s.Bid.Side.SetUint64(side)
+			return s, err
+		})
+		if err == nil {
+			t.Errorf("side %d: the round is accepted", side)
+		}
+	}
+}
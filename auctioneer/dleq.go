@@ -0,0 +1,67 @@
+package auctioneer
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// DLEQ is a Chaum-Pedersen proof that two points are raised to the same secret
+// x, A == g^x and B == h^x. It is made non-interactive with the MiMC hash of
+// the points: the prover draws k and publishes c == H(g, A, h, B, g^k, h^k)
+// and z == k + c·x, and the verifier recomputes g^k == g^z·A^-c and
+// h^k == h^z·B^-c.
+type DLEQ struct {
+	C fr.Element
+	Z fr.Element
+}
+
+// prove returns the proof that A == g^x and B == h^x
+func prove(x *big.Int, G, A, H, B *edwards.PointAffine) (DLEQ, error) {
+	curve := edwards.GetEdwardsCurve()
+	k, err := rand.Int(rand.Reader, &curve.Order)
+	if err != nil {
+		return DLEQ{}, err
+	}
+	var G_k, H_k edwards.PointAffine
+	G_k.ScalarMultiplication(G, k)
+	H_k.ScalarMultiplication(H, k)
+
+	var p DLEQ
+	p.C = challenge(G, A, H, B, &G_k, &H_k)
+	z := p.C.BigInt(new(big.Int))
+	z.Mul(z, x)
+	z.Add(z, k)
+	p.Z.SetBigInt(z.Mod(z, &curve.Order))
+	return p, nil
+}
+
+// Verify reports whether the proof shows that A == g^x and B == h^x for some x
+func (p *DLEQ) Verify(G, A, H, B *edwards.PointAffine) bool {
+	z, c := p.Z.BigInt(new(big.Int)), p.C.BigInt(new(big.Int))
+	var G_k, H_k, t edwards.PointAffine
+	G_k.ScalarMultiplication(G, z)
+	t.ScalarMultiplication(A, c)
+	t.Neg(&t)
+	G_k.Add(&G_k, &t)
+	H_k.ScalarMultiplication(H, z)
+	t.ScalarMultiplication(B, c)
+	t.Neg(&t)
+	H_k.Add(&H_k, &t)
+
+	expected := challenge(G, A, H, B, &G_k, &H_k)
+	return p.C.Equal(&expected)
+}
+
+// challenge returns H(g, A, h, B, g^k, h^k)
+func challenge(points ...*edwards.PointAffine) fr.Element {
+	elems := make([]fr.Element, 0, 2*len(points))
+	for _, p := range points {
+		elems = append(elems, p.X, p.Y)
+	}
+	return note.Hash(elems...)
+}
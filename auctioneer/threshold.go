@@ -0,0 +1,183 @@
+package auctioneer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/ps_threshold"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// Committee is the auctioneer when its secret key b is shared t-of-n between
+// the members of a committee, so that no member reads a bid alone. For each
+// registration, every member publishes its share g_r^{b_i} of the key with a
+// DLEQ proof against its public key g^{b_i}, and t valid shares are
+// Lagrange-combined into g_r^b once the bidding deadline has passed, the
+// invalid ones being discarded.
+//
+// The deadline is only enforced through the time now passed by the caller to
+// Member.Decrypt and Combine: the committee reads no clock of its own, so each
+// member must pass its own time.Now() rather than a time it is handed.
+type Committee struct {
+	// number of shares needed to decrypt a registration
+	T int
+	// public generator g and key g_b == g^b
+	G   edwards.PointAffine
+	G_b edwards.PointAffine
+	// public key g^{b_i} of the member of index i, at Keys[i-1]
+	Keys []edwards.PointAffine
+	// end of the bidding, before which no registration is decrypted
+	Deadline time.Time
+}
+
+// Member is a member of the committee
+type Member struct {
+	// index i of the share, from 1 to n
+	Index uint64
+	// share b_i of the secret key
+	B fr.Element
+	// public generator g
+	G        edwards.PointAffine
+	Deadline time.Time
+}
+
+// Share is the share g_r^{b_i} of the key of a registration, published by the
+// member of index i
+type Share struct {
+	Index   uint64
+	G_r_b_i edwards.PointAffine
+	// proof that g_r_b_i and the public key of the member are raised to b_i
+	Proof DLEQ
+}
+
+// NewCommittee shares a random secret key t-of-n between n members, for the
+// base point g of the twisted Edwards curve of BLS12-377. The key is dealt by
+// the caller, which must forget it: only the public keys are returned with the
+// members.
+func NewCommittee(t, n int, deadline time.Time) (*Committee, []Member, error) {
+	curve := edwards.GetEdwardsCurve()
+	shares, err := ps_threshold.Shares(t, n, &curve.Order)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &Committee{T: t, G: curve.Base, Keys: make([]edwards.PointAffine, n), Deadline: deadline}
+	c.G_b.ScalarMultiplication(&c.G, shares[0])
+	members := make([]Member, n)
+	for i := 1; i <= n; i++ {
+		members[i-1] = Member{Index: uint64(i), G: c.G, Deadline: deadline}
+		members[i-1].B.SetBigInt(shares[i])
+		c.Keys[i-1].ScalarMultiplication(&c.G, shares[i])
+	}
+	return c, members, nil
+}
+
+// Decrypt returns the share of the member of the key of the registration of
+// g_r, once the deadline has passed
+func (m *Member) Decrypt(G_r edwards.PointAffine, now time.Time) (Share, error) {
+	if now.Before(m.Deadline) {
+		return Share{}, fmt.Errorf("the registrations can't be decrypted before the deadline %s", m.Deadline.Format(time.RFC3339))
+	}
+	if !note.InSubgroup(&G_r) {
+		return Share{}, fmt.Errorf("g_r must be a point of the prime order subgroup of the twisted Edwards curve of BLS12-377")
+	}
+	b := m.B.BigInt(new(big.Int))
+	s := Share{Index: m.Index}
+	s.G_r_b_i.ScalarMultiplication(&G_r, b)
+	var key edwards.PointAffine
+	key.ScalarMultiplication(&m.G, b)
+	var err error
+	s.Proof, err = prove(b, &m.G, &key, &G_r, &s.G_r_b_i)
+	return s, err
+}
+
+// Verify checks the share of the key of the registration of g_r against the
+// public key of its member
+func (c *Committee) Verify(G_r edwards.PointAffine, s *Share) error {
+	if s.Index < 1 || s.Index > uint64(len(c.Keys)) {
+		return fmt.Errorf("no member of index %d", s.Index)
+	}
+	if !note.InSubgroup(&s.G_r_b_i) || !s.Proof.Verify(&c.G, &c.Keys[s.Index-1], &G_r, &s.G_r_b_i) {
+		return fmt.Errorf("the share of member %d isn't raised to its key", s.Index)
+	}
+	return nil
+}
+
+// Combine verifies the shares of the key of the registration of g_r, and
+// Lagrange-combines T valid ones, from distinct members, into g_r^b. The
+// invalid shares are discarded, so that a faulty member can't block the
+// decryption while T honest members publish theirs.
+func (c *Committee) Combine(G_r edwards.PointAffine, shares []Share, now time.Time) (edwards.PointAffine, error) {
+	var G_r_b edwards.PointAffine
+	if now.Before(c.Deadline) {
+		return G_r_b, fmt.Errorf("the registrations can't be decrypted before the deadline %s", c.Deadline.Format(time.RFC3339))
+	}
+	var valid []*Share
+	var invalid []error
+	seen := make(map[uint64]bool)
+	for i := range shares {
+		if len(valid) == c.T {
+			break
+		}
+		if s := &shares[i]; !seen[s.Index] {
+			if err := c.Verify(G_r, s); err != nil {
+				invalid = append(invalid, err)
+				continue
+			}
+			seen[s.Index] = true
+			valid = append(valid, s)
+		}
+	}
+	if len(valid) < c.T {
+		err := fmt.Errorf("%d valid shares of distinct members are needed, got %d", c.T, len(valid))
+		return G_r_b, errors.Join(append([]error{err}, invalid...)...)
+	}
+
+	// g_r^b == Sum(i)(l_i * g_r^{b_i})
+	curve := edwards.GetEdwardsCurve()
+	indices := make([]*big.Int, len(valid))
+	for i, s := range valid {
+		indices[i] = new(big.Int).SetUint64(s.Index)
+	}
+	coefficients, err := ps_threshold.LagrangeCoefficients(indices, &curve.Order)
+	if err != nil {
+		return G_r_b, err
+	}
+	G_r_b.X.SetZero()
+	G_r_b.Y.SetOne()
+	for i, s := range valid {
+		var term edwards.PointAffine
+		term.ScalarMultiplication(&s.G_r_b_i, coefficients[i])
+		G_r_b.Add(&G_r_b, &term)
+	}
+	return G_r_b, nil
+}
+
+// Decrypt decrypts the registration with the key combined from its shares, as
+// Auctioneer.Decrypt does
+func (c *Committee) Decrypt(reg *Registration, shares []Share, now time.Time) (witness.Secrets, error) {
+	if !note.InSubgroup(&reg.G_r) {
+		return witness.Secrets{}, fmt.Errorf("g_r must be a point of the prime order subgroup of the twisted Edwards curve of BLS12-377")
+	}
+	G_r_b, err := c.Combine(reg.G_r, shares, now)
+	if err != nil {
+		return witness.Secrets{}, err
+	}
+	return open(reg, G_r_b, c.G, c.G_b)
+}
+
+// Round decrypts the registrations of a round as Auctioneer.Round does,
+// shares[k] being the shares of the key of regs[k]
+func (c *Committee) Round(rule string, regs []Registration, shares [][]Share, now time.Time) (witness.Round, error) {
+	if len(shares) != len(regs) {
+		return witness.Round{}, fmt.Errorf("got the shares of %d registrations for %d", len(shares), len(regs))
+	}
+	return round(rule, len(regs), func(k int) (witness.Secrets, error) {
+		return c.Decrypt(&regs[k], shares[k], now)
+	})
+}
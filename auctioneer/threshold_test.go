@@ -0,0 +1,138 @@
+package auctioneer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// newCommitteeRegistration returns a 2-of-3 committee whose deadline has
+// passed, and a registration encrypted to it
+func newCommitteeRegistration(t *testing.T) (*Committee, []Member, witness.Secrets, Registration) {
+	t.Helper()
+	c, members, err := NewCommittee(2, 3, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := witness.RandomSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.G, s.G_b = c.G, c.G_b
+	reg, err := NewRegistration(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, members, s, reg
+}
+
+func TestCommitteeDecrypt(t *testing.T) {
+	c, members, s, reg := newCommitteeRegistration(t)
+	now := time.Now()
+	shares := make([]Share, len(members))
+	for i := range members {
+		var err error
+		if shares[i], err = members[i].Decrypt(reg.G_r, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// any T shares decrypt the registration
+	for _, pair := range [][]Share{shares[:2], shares[1:], {shares[2], shares[0]}} {
+		got, err := c.Decrypt(&reg, pair, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Bid != s.Bid || got.N_out.Cm != s.N_out.Cm {
+			t.Fatal("the committee decrypted another registration")
+		}
+	}
+
+	// a single share, or the same share twice, doesn't
+	if _, err := c.Decrypt(&reg, []Share{shares[0], shares[0]}, now); err == nil {
+		t.Fatal("the registration is decrypted with the share of a single member")
+	}
+	// nor does any share before the deadline
+	if _, err := members[0].Decrypt(reg.G_r, c.Deadline.Add(-time.Second)); err == nil {
+		t.Fatal("a member decrypted its share before the deadline")
+	}
+	if _, err := c.Decrypt(&reg, shares, c.Deadline.Add(-time.Second)); err == nil {
+		t.Fatal("the registration is decrypted before the deadline")
+	}
+}
+
+func TestCommitteeInvalidShare(t *testing.T) {
+	c, members, s, reg := newCommitteeRegistration(t)
+	now := time.Now()
+	shares := make([]Share, len(members))
+	for i := range members {
+		var err error
+		if shares[i], err = members[i].Decrypt(reg.G_r, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first member publishes another point, with a proof that doesn't
+	// hold for it
+	shares[0].G_r_b_i.Add(&shares[0].G_r_b_i, &c.G)
+	if err := c.Verify(reg.G_r, &shares[0]); err == nil {
+		t.Fatal("a share not raised to the key of its member is accepted")
+	}
+	// and the second one a share of the right point with a forged proof
+	forged := shares[1]
+	forged.Proof.Z.SetOne()
+	if err := c.Verify(reg.G_r, &forged); err == nil {
+		t.Fatal("a share of a forged proof is accepted")
+	}
+
+	// the T valid shares left still decrypt the registration
+	got, err := c.Decrypt(&reg, append([]Share{forged}, shares...), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bid != s.Bid || got.N_out.Cm != s.N_out.Cm {
+		t.Fatal("the committee decrypted another registration")
+	}
+	if _, err := c.Decrypt(&reg, shares[:2], now); err == nil {
+		t.Fatal("the registration is decrypted with a single valid share")
+	}
+}
+
+func TestProve(t *testing.T) {
+	a, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := witness.RandomSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.G, s.G_b = a.G, a.G_b
+	reg, err := NewRegistration(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := a.Prove(&reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.Decrypt(&reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Verify(&a.G, &a.G_b, &reg.G_r, &got.G_r_b) {
+		t.Fatal("the proof of g_r_b doesn't verify")
+	}
+
+	// the proof doesn't hold for another point, or with another challenge
+	other := got.G_r_b
+	other.Add(&other, &a.G)
+	if p.Verify(&a.G, &a.G_b, &reg.G_r, &other) {
+		t.Fatal("the proof verifies for another point")
+	}
+	p.C.SetOne()
+	if p.Verify(&a.G, &a.G_b, &reg.G_r, &got.G_r_b) {
+		t.Fatal("a forged proof verifies")
+	}
+}
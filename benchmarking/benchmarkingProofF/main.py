@@ -11,24 +11,30 @@ def remove_ansi_escape_sequences(text):
     ansi_escape = re.compile(r'\x1b\[[0-9;]*m')
     return ansi_escape.sub('', text)
 
+def extract_time(output, name):
+    """
+    Extract the duration in ms printed as "<name> time: <ms> ms" by main.go.
+    """
+    match = re.search(name + r' time: ([0-9.]+) ms', remove_ansi_escape_sequences(output))
+    if match is None:
+        return None
+    return float(match.group(1))
+
 def extract_prover_time(output):
     """
     Extract the prover time from the command output.
     """
-    lines = (output.split('\n')[4].split('='))
-
-    return float(remove_ansi_escape_sequences(lines[5]))
+    return extract_time(output, 'Prover')
 
 def extract_verification_time(output):
     """
     Extract the verification time from the command output.
     """
-    lines = (output.split('\n')[5].split('='))
-
-    return float(remove_ansi_escape_sequences(lines[3]))
+    return extract_time(output, 'Verifier')
 
 def run_go_command_in_subfolders(base_dir):
-    folder_paths = ["./ProofF_10", "./ProofF_20", "./ProofF_30", "./ProofF_40", "./ProofF_50", "./ProofF_60", "./ProofF_70", "./ProofF_80", "./ProofF_90", "./ProofF_100", "./ProofF_110", "./ProofF_120", "./ProofF_130", "./ProofF_140", "./ProofF_150", "./ProofF_160"]
+    # Un seul circuit ProofF, compilé pour n/2 acheteurs et n/2 vendeurs
+    folder_path = "../../ProofF"
     index = [10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110, 120, 130, 140, 150, 160]
     # Liste pour stocker les temps d'exécution
     execution_times = []
@@ -37,16 +43,15 @@ def run_go_command_in_subfolders(base_dir):
 
     g = 0
     
-    for folder_path in folder_paths:
+    for n in index:
         g+=1
-        # Parcours des sous-dossiers
-        print(f"Exécution dans le dossier: {folder_path}")
+        print(f"Exécution avec {n // 2} acheteurs et {n // 2} vendeurs")
                 
         # Enregistrer le temps de début
         start_time = time.time()
                 
-        # Exécuter la commande go run main.go
-        result = subprocess.run(['go', 'run', 'main.go'], cwd=folder_path, capture_output=True, text=True)
+        # Exécuter la commande go run main.go -buyers <n/2> -sellers <n/2>
+        result = subprocess.run(['go', 'run', 'main.go', '-buyers', str(n // 2), '-sellers', str(n // 2)], cwd=folder_path, capture_output=True, text=True)
                 
         # Enregistrer le temps de fin
         end_time = time.time()
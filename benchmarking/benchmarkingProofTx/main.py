@@ -11,26 +11,33 @@ def remove_ansi_escape_sequences(text):
     ansi_escape = re.compile(r'\x1b\[[0-9;]*m')
     return ansi_escape.sub('', text)
 
+def extract_time(output, name):
+    """
+    Extract the duration in ms printed as "<name> time: <ms> ms" by main.go.
+    """
+    match = re.search(name + r' time: ([0-9.]+) ms', remove_ansi_escape_sequences(output))
+    if match is None:
+        return None
+    return float(match.group(1))
+
 def extract_prover_time(output):
     """
     Extract the prover time from the command output.
     """
-    lines = (output.split('\n')[4].split('='))
-
-    return float(remove_ansi_escape_sequences(lines[5]))
+    return extract_time(output, 'Prover')
 
 def extract_verification_time(output):
     """
     Extract the verification time from the command output.
     """
-    lines = (output.split('\n')[5].split('='))
-
-    return float(remove_ansi_escape_sequences(lines[3]))
+    return extract_time(output, 'Verifier')
 
 def run_go_command_in_subfolders(base_dir):
-    folder_paths = ["./ProofTx_8", "./ProofTx_16", "./ProofTx_32", "./ProofTx_64", "./ProofTx_100", "./ProofTx_128", "./ProofTx_160"]
+    # Un seul circuit ProofTx, compilé pour chaque couple (l, h)
+    folder_path = "../../ProofTx"
+    shapes = [(8, 3), (16, 4), (32, 5), (64, 6), (100, 7), (128, 7), (160, 8)]
                     
-    index = [8, 16, 32, 64, 100, 128, 160]
+    index = [l for (l, h) in shapes]
     # Liste pour stocker les temps d'exécution
     execution_times = []
     prover_times = []
@@ -38,16 +45,15 @@ def run_go_command_in_subfolders(base_dir):
 
     g = 0
     
-    for folder_path in folder_paths:
+    for (l, h) in shapes:
         g+=1
-        # Parcours des sous-dossiers
-        print(f"Exécution dans le dossier: {folder_path}")
+        print(f"Exécution avec l={l} et h={h}")
                 
         # Enregistrer le temps de début
         start_time = time.time()
                 
-        # Exécuter la commande go run main.go
-        result = subprocess.run(['go', 'run', 'main.go'], cwd=folder_path, capture_output=True, text=True)
+        # Exécuter la commande go run main.go -l <l> -m <l> -h <h>
+        result = subprocess.run(['go', 'run', 'main.go', '-l', str(l), '-m', str(l), '-h', str(h)], cwd=folder_path, capture_output=True, text=True)
                 
         # Enregistrer le temps de fin
         end_time = time.time()
@@ -0,0 +1,174 @@
+// Package bid defines the bids of the energy market: a side (buy or sell), a
+// delivery slot, and a curve of Steps steps, each one offering a quantity in
+// Wh at a limit price. The prices of a buy curve are non-increasing and those
+// of a sell curve non-decreasing; unused steps have a zero quantity.
+//
+// Each step is packed into one field element encrypted in ProofReg,
+//
+//	b_j = Price_j + 2^ValueBits·Quantity_j + 2^(2·ValueBits)·Slot + 2^(2·ValueBits+SlotBits)·Side
+//
+// and the circuits unpack the bid with Unpack, which range checks every field
+// so that the decomposition is unique, and enforces the monotonicity of the
+// curve.
+package bid
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+func init() {
+	solver.RegisterHint(unpackHint)
+}
+
+// sides of a bid
+const (
+	Buy  = 0
+	Sell = 1
+)
+
+// Steps is the number of steps of a bid curve. Changing it changes the
+// circuits.
+const Steps = 4
+
+// SlotBits is the size of the delivery slots, e.g. hours since an epoch.
+// Changing it changes the circuits.
+const SlotBits = 32
+
+// Bid is the in-circuit version of NativeBid
+type Bid struct {
+	// Buy or Sell
+	Side frontend.Variable
+	// delivery slot
+	Slot frontend.Variable
+	// curve, in decreasing order of price for a buy bid and in increasing
+	// order for a sell bid
+	Steps [Steps]Step
+}
+
+// Step is a step of a bid curve
+type Step struct {
+	// quantity in Wh
+	Quantity frontend.Variable
+	// limit price
+	Price frontend.Variable
+}
+
+// Unpack returns the bid whose steps are packed in b, each field being range
+// checked and the curve being monotonic
+func Unpack(api frontend.API, b [Steps]frontend.Variable) (Bid, error) {
+	if packedBits() >= api.Compiler().FieldBitLen() {
+		return Bid{}, fmt.Errorf("a step of %d bits doesn't fit in the field", packedBits())
+	}
+	var bid Bid
+	rc := rangecheck.New(api)
+	for j := range b {
+		res, err := api.Compiler().NewHint(unpackHint, 4, b[j])
+		if err != nil {
+			return Bid{}, err
+		}
+		step := Step{Price: res[0], Quantity: res[1]}
+		side, slot := res[3], res[2]
+
+		api.AssertIsBoolean(side)
+		rc.Check(slot, SlotBits)
+		note.AssertIsValue(api, step.Quantity, step.Price)
+		api.AssertIsEqual(b[j], pack(api, side, slot, step))
+
+		// every step shares the side and the slot of the first one
+		if j == 0 {
+			bid.Side, bid.Slot = side, slot
+		} else {
+			api.AssertIsEqual(side, bid.Side)
+			api.AssertIsEqual(slot, bid.Slot)
+		}
+		bid.Steps[j] = step
+	}
+
+	// the prices decrease along a buy curve and increase along a sell curve,
+	// the difference of two prices fitting in ValueBits bits when it is
+	// non-negative
+	for j := 1; j < Steps; j++ {
+		prev, next := bid.Steps[j-1].Price, bid.Steps[j].Price
+		note.AssertIsValue(api, api.Select(bid.Side, api.Sub(next, prev), api.Sub(prev, next)))
+	}
+	return bid, nil
+}
+
+// Pack returns the packed steps of the bid
+func Pack(api frontend.API, bid Bid) [Steps]frontend.Variable {
+	var res [Steps]frontend.Variable
+	for j := range bid.Steps {
+		res[j] = pack(api, bid.Side, bid.Slot, bid.Steps[j])
+	}
+	return res
+}
+
+// pack returns the packed step
+func pack(api frontend.API, side, slot frontend.Variable, step Step) frontend.Variable {
+	res := frontend.Variable(0)
+	shifts := shifts()
+	for i, v := range [4]frontend.Variable{step.Price, step.Quantity, slot, side} {
+		res = api.Add(res, api.Mul(v, shifts[i]))
+	}
+	return res
+}
+
+// packedBits returns the size of a packed step
+func packedBits() int {
+	return 2*note.ValueBits + SlotBits + 1
+}
+
+// shifts returns the weights of the fields of a packed step, from the least
+// significant one
+func shifts() [4]*big.Int {
+	var res [4]*big.Int
+	offset := 0
+	for i, bits := range widths() {
+		res[i] = new(big.Int).Lsh(big.NewInt(1), uint(offset))
+		offset += bits
+	}
+	return res
+}
+
+// widths returns the sizes of the fields of a packed step, from the least
+// significant one
+func widths() [4]int {
+	return [4]int{note.ValueBits, note.ValueBits, SlotBits, 1}
+}
+
+// unpackHint returns the fields of the packed step inputs[0], from the least
+// significant one
+func unpackHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != 1 || len(outputs) != 4 {
+		return fmt.Errorf("unpackHint expects a packed step")
+	}
+	for i, v := range split(inputs[0]) {
+		outputs[i].Set(v)
+	}
+	return nil
+}
+
+// split returns the fields of the packed step b from the least significant
+// one, the last one holding the bits left
+func split(b *big.Int) [4]*big.Int {
+	var res [4]*big.Int
+	rest := new(big.Int).Set(b)
+	for i, bits := range widths() {
+		if i == len(res)-1 {
+			res[i] = rest
+			break
+		}
+		mask := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		mask.Sub(mask, big.NewInt(1))
+		res[i] = new(big.Int).And(rest, mask)
+		rest.Rsh(rest, uint(bits))
+	}
+	return res
+}
@@ -0,0 +1,166 @@
+package bid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// NativeBid is a bid of the energy market
+type NativeBid struct {
+	// Buy or Sell
+	Side fr.Element
+	// delivery slot
+	Slot fr.Element
+	// curve, in decreasing order of price for a buy bid and in increasing
+	// order for a sell bid
+	Steps [Steps]NativeStep
+}
+
+// NativeStep is a step of a bid curve
+type NativeStep struct {
+	// quantity in Wh
+	Quantity fr.Element
+	// limit price
+	Price fr.Element
+}
+
+// NewNativeBid returns the bid of the given side and slot offering the
+// quantities at the prices, the steps left being unused
+func NewNativeBid(side, slot uint64, quantities, prices []uint64) (NativeBid, error) {
+	var b NativeBid
+	if len(quantities) != len(prices) || len(prices) == 0 || len(prices) > Steps {
+		return b, fmt.Errorf("a bid has between 1 and %d steps, got %d quantities and %d prices", Steps, len(quantities), len(prices))
+	}
+	b.Side.SetUint64(side)
+	b.Slot.SetUint64(slot)
+	for j := range b.Steps {
+		// unused steps repeat the last price, so that the curve stays monotonic
+		k := min(j, len(prices)-1)
+		b.Steps[j].Price.SetUint64(prices[k])
+		if j == k {
+			b.Steps[j].Quantity.SetUint64(quantities[k])
+		}
+	}
+	return b, b.Check()
+}
+
+// RandomNativeBid draws the quantities and the prices of a bid of the given
+// side and slot. They are drawn below 2^(note.ValueBits/2-2), so that the Cost
+// of the bid fits in note.ValueBits-2 bits and a note can pay for it.
+func RandomNativeBid(side, slot uint64) (NativeBid, error) {
+	var b NativeBid
+	b.Side.SetUint64(side)
+	b.Slot.SetUint64(slot)
+	max := new(big.Int).Lsh(big.NewInt(1), uint(note.ValueBits/2-2))
+	prices := make([]*big.Int, Steps)
+	for j := range b.Steps {
+		q, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return b, err
+		}
+		b.Steps[j].Quantity.SetBigInt(q)
+		if prices[j], err = rand.Int(rand.Reader, max); err != nil {
+			return b, err
+		}
+	}
+	sort.Slice(prices, func(a, c int) bool {
+		if side == Buy {
+			return prices[a].Cmp(prices[c]) > 0
+		}
+		return prices[a].Cmp(prices[c]) < 0
+	})
+	for j := range b.Steps {
+		b.Steps[j].Price.SetBigInt(prices[j])
+	}
+	return b, nil
+}
+
+// Cost returns the amount paid for the whole curve at its limit prices, the
+// most a buyer pays in a round
+func (b *NativeBid) Cost() fr.Element {
+	var res fr.Element
+	for j := range b.Steps {
+		var amount fr.Element
+		amount.Mul(&b.Steps[j].Quantity, &b.Steps[j].Price)
+		res.Add(&res, &amount)
+	}
+	return res
+}
+
+// Check returns an error when the bid can't be packed or isn't a curve: the
+// side must be Buy or Sell, the slot must fit in SlotBits bits, the quantities
+// and the prices in note.ValueBits bits, and the prices must be monotonic
+func (b *NativeBid) Check() error {
+	if !b.Side.IsUint64() || b.Side.Uint64() > Sell {
+		return fmt.Errorf("the side of a bid must be %d (buy) or %d (sell)", Buy, Sell)
+	}
+	if b.Slot.BigInt(new(big.Int)).BitLen() > SlotBits {
+		return fmt.Errorf("the slot doesn't fit in %d bits", SlotBits)
+	}
+	for j := range b.Steps {
+		if err := note.CheckValue(fmt.Sprintf("the quantity of step %d", j), &b.Steps[j].Quantity); err != nil {
+			return err
+		}
+		if err := note.CheckValue(fmt.Sprintf("the price of step %d", j), &b.Steps[j].Price); err != nil {
+			return err
+		}
+	}
+	for j := 1; j < Steps; j++ {
+		c := b.Steps[j-1].Price.Cmp(&b.Steps[j].Price)
+		if b.Side.Uint64() == Buy && c < 0 || b.Side.Uint64() == Sell && c > 0 {
+			return fmt.Errorf("the prices of a buy bid must decrease and those of a sell bid increase")
+		}
+	}
+	return nil
+}
+
+// Pack returns the packed steps of the bid, as Pack does in-circuit
+func (b *NativeBid) Pack() [Steps]fr.Element {
+	var res [Steps]fr.Element
+	shifts := shifts()
+	for j := range b.Steps {
+		for i, f := range [4]*fr.Element{&b.Steps[j].Price, &b.Steps[j].Quantity, &b.Slot, &b.Side} {
+			var v fr.Element
+			v.SetBigInt(shifts[i])
+			v.Mul(&v, f)
+			res[j].Add(&res[j], &v)
+		}
+	}
+	return res
+}
+
+// NativeUnpack returns the bid whose steps are packed in b, as Unpack does
+// in-circuit
+func NativeUnpack(b [Steps]fr.Element) (NativeBid, error) {
+	var res NativeBid
+	for j := range b {
+		fields := split(b[j].BigInt(new(big.Int)))
+		var side, slot fr.Element
+		res.Steps[j].Price.SetBigInt(fields[0])
+		res.Steps[j].Quantity.SetBigInt(fields[1])
+		slot.SetBigInt(fields[2])
+		side.SetBigInt(fields[3])
+		if j == 0 {
+			res.Side, res.Slot = side, slot
+		} else if !side.Equal(&res.Side) || !slot.Equal(&res.Slot) {
+			return res, fmt.Errorf("the steps of a bid must share the side and the slot")
+		}
+	}
+	return res, res.Check()
+}
+
+// Variable returns the packed steps of the bid as a circuit assignment
+func (b *NativeBid) Variable() [Steps]frontend.Variable {
+	var res [Steps]frontend.Variable
+	for j, e := range b.Pack() {
+		res[j] = note.Variable(e)
+	}
+	return res
+}
@@ -0,0 +1,419 @@
+// Package bundle ships a proof with everything needed to verify it later and
+// elsewhere: the public witness, the circuit it was produced for and the hash
+// of the verifying key it is checked with.
+//
+// A bundle has a binary encoding (MarshalBinary) and a JSON one (MarshalJSON),
+// in which the proof is hex encoded and the public inputs are decimal strings,
+// each one below the modulus of the scalar field of the curve.
+package bundle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	stdgroth16 "github.com/consensys/gnark/std/recursion/groth16"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/keys"
+)
+
+// Version of the encodings
+const Version = 1
+
+var magic = [4]byte{'P', 'P', 'E', 'B'}
+
+// ErrMismatch is returned when a bundle is verified against the keys of
+// another circuit
+var ErrMismatch = errors.New("proof bundle doesn't match the verifying key")
+
+type Bundle struct {
+	// circuit identifier and parameters, as in keys.Header
+	Circuit string
+	Params  string
+	// curve and proving system
+	Curve   string
+	Backend string
+	// sha256 of the verifying key (see keys.HashVerifyingKey)
+	VKHash [32]byte
+	// proof, as written by its WriteTo method
+	Proof []byte
+	// public witness
+	Public witness.Witness
+}
+
+// New bundles a proof produced with the keys described by h
+func New(h keys.Header, vk, proof io.WriterTo, public witness.Witness) (*Bundle, error) {
+	vkHash, err := keys.HashVerifyingKey(vk)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return &Bundle{
+		Circuit: h.Circuit,
+		Params:  h.Params,
+		Curve:   h.Curve,
+		Backend: h.Backend,
+		VKHash:  vkHash,
+		Proof:   buf.Bytes(),
+		Public:  public,
+	}, nil
+}
+
+// Check returns ErrMismatch if the bundle was not produced for the keys
+// described by h and whose verifying key is vk
+func (b *Bundle) Check(h keys.Header, vk io.WriterTo) error {
+	switch {
+	case b.Circuit != h.Circuit:
+		return fmt.Errorf("%w: circuit %q, expected %q", ErrMismatch, b.Circuit, h.Circuit)
+	case b.Params != h.Params:
+		return fmt.Errorf("%w: parameters %q, expected %q", ErrMismatch, b.Params, h.Params)
+	case b.Curve != h.Curve:
+		return fmt.Errorf("%w: curve %s, expected %s", ErrMismatch, b.Curve, h.Curve)
+	case b.Backend != h.Backend:
+		return fmt.Errorf("%w: backend %s, expected %s", ErrMismatch, b.Backend, h.Backend)
+	}
+	vkHash, err := keys.HashVerifyingKey(vk)
+	if err != nil {
+		return err
+	}
+	if vkHash != b.VKHash {
+		return fmt.Errorf("%w: produced for another verifying key", ErrMismatch)
+	}
+	return nil
+}
+
+// Prove proves the assignment with the keys of s and bundles the proof
+func Prove(s *keys.Setup, assignment frontend.Circuit) (*Bundle, error) {
+	w, err := frontend.NewWitness(assignment, s.CCS.Field())
+	if err != nil {
+		return nil, err
+	}
+	public, err := w.Public()
+	if err != nil {
+		return nil, err
+	}
+
+	var proof io.WriterTo
+	switch pk := s.PK.(type) {
+	case groth16.ProvingKey:
+		proof, err = groth16.Prove(s.CCS, pk, w, groth16ProverOptions(pk.CurveID())...)
+	case plonk.ProvingKey:
+		proof, err = plonk.Prove(s.CCS, pk, w)
+	default:
+		err = fmt.Errorf("unsupported proving key %T", s.PK)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return New(s.Header, s.VK, proof, public)
+}
+
+// Verify checks the bundle against the keys described by h and verifies the
+// proof with vk, a groth16 or plonk verifying key
+func (b *Bundle) Verify(h keys.Header, vk io.WriterTo) error {
+	if err := b.Check(h, vk); err != nil {
+		return err
+	}
+	curve, err := ecc.IDFromString(b.Curve)
+	if err != nil {
+		return err
+	}
+	switch b.Backend {
+	case backend.GROTH16.String():
+		vk, ok := vk.(groth16.VerifyingKey)
+		if !ok {
+			break
+		}
+		proof, err := b.Groth16Proof()
+		if err != nil {
+			return err
+		}
+		return groth16.Verify(proof, vk, b.Public, groth16VerifierOptions(curve)...)
+	case backend.PLONK.String():
+		vk, ok := vk.(plonk.VerifyingKey)
+		if !ok {
+			break
+		}
+		proof := plonk.NewProof(curve)
+		if _, err := proof.ReadFrom(bytes.NewReader(b.Proof)); err != nil {
+			return err
+		}
+		return plonk.Verify(proof, vk, b.Public)
+	}
+	return fmt.Errorf("%w: backend %s, verifying key %T", ErrMismatch, b.Backend, vk)
+}
+
+// Groth16Proof decodes the groth16 proof of the bundle
+func (b *Bundle) Groth16Proof() (groth16.Proof, error) {
+	if b.Backend != backend.GROTH16.String() {
+		return nil, fmt.Errorf("not a groth16 proof bundle but a %s one", b.Backend)
+	}
+	curve, err := ecc.IDFromString(b.Curve)
+	if err != nil {
+		return nil, err
+	}
+	proof := groth16.NewProof(curve)
+	if _, err := proof.ReadFrom(bytes.NewReader(b.Proof)); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// groth16ProverOptions returns the options making the groth16 proofs on
+// BLS12-377 verifiable in-circuit on BW6-761 (see package aggregate)
+func groth16ProverOptions(curve ecc.ID) []backend.ProverOption {
+	if curve != ecc.BLS12_377 {
+		return nil
+	}
+	return []backend.ProverOption{stdgroth16.GetNativeProverOptions(ecc.BW6_761.ScalarField(), curve.ScalarField())}
+}
+
+// groth16VerifierOptions returns the verifier options matching
+// groth16ProverOptions
+func groth16VerifierOptions(curve ecc.ID) []backend.VerifierOption {
+	if curve != ecc.BLS12_377 {
+		return nil
+	}
+	return []backend.VerifierOption{stdgroth16.GetNativeVerifierOptions(ecc.BW6_761.ScalarField(), curve.ScalarField())}
+}
+
+// field returns the scalar field of the curve of the bundle
+func (b *Bundle) field() (*big.Int, error) {
+	curve, err := ecc.IDFromString(b.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return curve.ScalarField(), nil
+}
+
+// MarshalBinary encodes the bundle: magic, version, the length-prefixed
+// strings, the verifying key hash, then the length-prefixed proof and public
+// witness
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	public, err := b.Public.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(magic[:])
+	binary.Write(&buf, binary.BigEndian, uint16(Version))
+	for _, s := range []string{b.Circuit, b.Params, b.Curve, b.Backend} {
+		binary.Write(&buf, binary.BigEndian, uint16(len(s)))
+		buf.WriteString(s)
+	}
+	buf.Write(b.VKHash[:])
+	for _, data := range [][]byte{b.Proof, public} {
+		binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a bundle encoded by MarshalBinary
+func (b *Bundle) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	var m [4]byte
+	var version uint16
+	if err := binary.Read(r, binary.BigEndian, &m); err != nil {
+		return err
+	}
+	if m != magic {
+		return fmt.Errorf("not a proof bundle")
+	}
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return err
+	}
+	if version != Version {
+		return fmt.Errorf("unsupported proof bundle version %d", version)
+	}
+	for _, s := range []*string{&b.Circuit, &b.Params, &b.Curve, &b.Backend} {
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return err
+		}
+		v := make([]byte, length)
+		if _, err := io.ReadFull(r, v); err != nil {
+			return err
+		}
+		*s = string(v)
+	}
+	if _, err := io.ReadFull(r, b.VKHash[:]); err != nil {
+		return err
+	}
+	var fields [2][]byte
+	for i := range fields {
+		var length uint32
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return err
+		}
+		if int64(length) > int64(r.Len()) {
+			return io.ErrUnexpectedEOF
+		}
+		fields[i] = make([]byte, length)
+		if _, err := io.ReadFull(r, fields[i]); err != nil {
+			return err
+		}
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("trailing data after the proof bundle")
+	}
+
+	field, err := b.field()
+	if err != nil {
+		return err
+	}
+	b.Proof = fields[0]
+	if b.Public, err = witness.New(field); err != nil {
+		return err
+	}
+	return b.Public.UnmarshalBinary(fields[1])
+}
+
+// bundleJSON is the JSON encoding of a bundle
+type bundleJSON struct {
+	Version int      `json:"version"`
+	Circuit string   `json:"circuit"`
+	Params  string   `json:"params"`
+	Curve   string   `json:"curve"`
+	Backend string   `json:"backend"`
+	VKHash  string   `json:"vk_hash"`
+	Proof   string   `json:"proof"`
+	Public  []string `json:"public"`
+}
+
+// MarshalJSON encodes the bundle in JSON
+func (b *Bundle) MarshalJSON() ([]byte, error) {
+	public, err := publicInputs(b.Public)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(bundleJSON{
+		Version: Version,
+		Circuit: b.Circuit,
+		Params:  b.Params,
+		Curve:   b.Curve,
+		Backend: b.Backend,
+		VKHash:  hex.EncodeToString(b.VKHash[:]),
+		Proof:   hex.EncodeToString(b.Proof),
+		Public:  public,
+	})
+}
+
+// UnmarshalJSON decodes a bundle encoded by MarshalJSON
+func (b *Bundle) UnmarshalJSON(data []byte) error {
+	var v bundleJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version != Version {
+		return fmt.Errorf("unsupported proof bundle version %d", v.Version)
+	}
+	vkHash, err := hex.DecodeString(v.VKHash)
+	if err != nil {
+		return fmt.Errorf("vk_hash: %w", err)
+	}
+	if len(vkHash) != len(b.VKHash) {
+		return fmt.Errorf("vk_hash must be %d bytes long", len(b.VKHash))
+	}
+	proof, err := hex.DecodeString(v.Proof)
+	if err != nil {
+		return fmt.Errorf("proof: %w", err)
+	}
+
+	b.Circuit, b.Params, b.Curve, b.Backend = v.Circuit, v.Params, v.Curve, v.Backend
+	copy(b.VKHash[:], vkHash)
+	b.Proof = proof
+
+	field, err := b.field()
+	if err != nil {
+		return err
+	}
+	if b.Public, err = witness.New(field); err != nil {
+		return err
+	}
+	values := make(chan any, len(v.Public))
+	for i, s := range v.Public {
+		e, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return fmt.Errorf("public input %d is not a decimal number", i)
+		}
+		// the witness would reduce the value, so that two encodings would
+		// decode to the same bundle
+		if e.Sign() < 0 || e.Cmp(field) >= 0 {
+			return fmt.Errorf("public input %d is not an element of the scalar field of %s", i, b.Curve)
+		}
+		values <- e
+	}
+	close(values)
+	return b.Public.Fill(len(v.Public), 0, values)
+}
+
+// publicInputs returns the public inputs of the witness as decimal strings
+func publicInputs(public witness.Witness) ([]string, error) {
+	// the vector is a fr.Vector of the curve of the witness
+	vector := reflect.ValueOf(public.Vector())
+	if vector.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("unexpected witness vector %T", public.Vector())
+	}
+	res := make([]string, vector.Len())
+	for i := range res {
+		e, ok := vector.Index(i).Addr().Interface().(interface{ BigInt(*big.Int) *big.Int })
+		if !ok {
+			return nil, fmt.Errorf("unexpected witness vector %T", public.Vector())
+		}
+		res[i] = e.BigInt(new(big.Int)).String()
+	}
+	return res, nil
+}
+
+// WriteFile writes the bundle to path, in JSON if its extension is .json and
+// in binary otherwise
+func (b *Bundle) WriteFile(path string) error {
+	var data []byte
+	var err error
+	if filepath.Ext(path) == ".json" {
+		data, err = json.MarshalIndent(b, "", "  ")
+	} else {
+		data, err = b.MarshalBinary()
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadFile reads a bundle written by WriteFile
+func ReadFile(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := new(Bundle)
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, b)
+	} else {
+		err = b.UnmarshalBinary(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
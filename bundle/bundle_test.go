@@ -0,0 +1,133 @@
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/keys"
+)
+
+// squareCircuit proves the knowledge of a square root of Y
+type squareCircuit struct {
+	Y frontend.Variable `gnark:",public"`
+	X frontend.Variable
+}
+
+func (c *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(c.Y, api.Mul(c.X, c.X))
+	return nil
+}
+
+// newSetup runs the groth16 setup of squareCircuit in a new directory
+func newSetup(t *testing.T) *keys.Setup {
+	t.Helper()
+	s, err := keys.New(keys.Curve, backend.GROTH16, t.TempDir(), "square", "", new(squareCircuit), keys.SRS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// prove bundles a proof that 3 is a square root of 9
+func prove(t *testing.T, s *keys.Setup) *Bundle {
+	t.Helper()
+	b, err := Prove(s, &squareCircuit{Y: 9, X: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Verify(s.Header, s.VK); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := newSetup(t)
+	b := prove(t, s)
+	for name, roundTrip := range map[string]func() (*Bundle, error){
+		"binary": func() (*Bundle, error) {
+			data, err := b.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			res := new(Bundle)
+			return res, res.UnmarshalBinary(data)
+		},
+		"JSON": func() (*Bundle, error) {
+			data, err := json.Marshal(b)
+			if err != nil {
+				return nil, err
+			}
+			res := new(Bundle)
+			return res, json.Unmarshal(data, res)
+		},
+	} {
+		decoded, err := roundTrip()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := decoded.Verify(s.Header, s.VK); err != nil {
+			t.Fatalf("%s: the decoded bundle doesn't verify: %v", name, err)
+		}
+		public, err := publicInputs(decoded.Public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(public) != 1 || public[0] != "9" {
+			t.Fatalf("%s: decoded the public inputs %v, expected [9]", name, public)
+		}
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	s := newSetup(t)
+	b := prove(t, s)
+	// another setup of the same circuit has other keys
+	other := newSetup(t)
+
+	for name, h := range map[string]keys.Header{
+		"circuit":           {Circuit: "other", Params: s.Header.Params, Curve: s.Header.Curve, Backend: s.Header.Backend},
+		"parameters":        {Circuit: s.Header.Circuit, Params: "n=2", Curve: s.Header.Curve, Backend: s.Header.Backend},
+		"backend":           {Circuit: s.Header.Circuit, Params: s.Header.Params, Curve: s.Header.Curve, Backend: backend.PLONK.String()},
+		"curve":             {Circuit: s.Header.Circuit, Params: s.Header.Params, Curve: "bn254", Backend: s.Header.Backend},
+		"the verifying key": other.Header,
+	} {
+		vk := s.VK
+		if name == "the verifying key" {
+			vk = other.VK
+		}
+		if err := b.Verify(h, vk); !errors.Is(err, ErrMismatch) {
+			t.Errorf("another %s: got %v, expected ErrMismatch", name, err)
+		}
+	}
+}
+
+func TestUnmarshalJSONModulus(t *testing.T) {
+	s := newSetup(t)
+	b := prove(t, s)
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v bundleJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	modulus := keys.Curve.ScalarField()
+	// 9 + r would decode to 9
+	for _, public := range []string{modulus.String(), new(big.Int).Add(modulus, big.NewInt(9)).String(), "-9"} {
+		v.Public = []string{public}
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, new(Bundle)); err == nil {
+			t.Errorf("the public input %s is decoded", public)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package aggregate implements the aggregation of an auction round: N ProofReg
+// proofs, proven with groth16 on BLS12-377, are verified inside one proof on
+// BW6-761, the other curve of the two-chain. The only public input of the
+// aggregate proof is the MiMC digest of the public inputs of every
+// registration (commitments, ciphertexts, g_r, g and g_b), so that the ledger
+// verifies a single proof against the registrations it recorded.
+//
+// The registration proofs must be produced with the prover options of
+// stdgroth16.GetNativeProverOptions, which package bundle uses for every
+// groth16 proof on BLS12-377.
+package aggregate
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377_fr "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	mimc_bw6_761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/emulated"
+	stdgroth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// Curve the aggregate proof is proven on
+var Curve = ecc.BW6_761
+
+type (
+	// in-circuit groth16 proof on BLS12-377
+	Proof = stdgroth16.Proof[sw_bls12377.G1Affine, sw_bls12377.G2Affine]
+	// in-circuit public witness of a proof on BLS12-377
+	Witness = stdgroth16.Witness[sw_bls12377.ScalarField]
+	// in-circuit groth16 verifying key on BLS12-377
+	VerifyingKey = stdgroth16.VerifyingKey[sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]
+)
+
+// variable names must start with a capital letter
+type AggregateCircuit struct {
+	//public inputs
+	Digest frontend.Variable `gnark:",public"`
+
+	//secret inputs
+	Proofs    []Proof
+	Witnesses []Witness
+
+	// verifying key of ProofReg, fixed in the circuit
+	VerifyingKey VerifyingKey `gnark:"-"`
+}
+
+// NewCircuit returns the circuit aggregating n proofs of the inner circuit,
+// compiled in innerCCS and whose verifying key is innerVK
+func NewCircuit(n int, innerCCS constraint.ConstraintSystem, innerVK groth16.VerifyingKey) (*AggregateCircuit, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of proofs %d", n)
+	}
+	vk, err := stdgroth16.ValueOfVerifyingKeyFixed[sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](innerVK)
+	if err != nil {
+		return nil, err
+	}
+	// the range checks of ProofReg commit to some of its wires, which the
+	// fixed verifying key doesn't record
+	vk.PublicAndCommitmentCommitted = stdgroth16.PlaceholderVerifyingKey[sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](innerCCS).PublicAndCommitmentCommitted
+	circuit := &AggregateCircuit{
+		Proofs:       make([]Proof, n),
+		Witnesses:    make([]Witness, n),
+		VerifyingKey: vk,
+	}
+	for i := 0; i < n; i++ {
+		circuit.Proofs[i] = stdgroth16.PlaceholderProof[sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerCCS)
+		circuit.Witnesses[i] = stdgroth16.PlaceholderWitness[sw_bls12377.ScalarField](innerCCS)
+	}
+	return circuit, nil
+}
+
+func (circuit *AggregateCircuit) Define(api frontend.API) error {
+
+	if len(circuit.Proofs) == 0 || len(circuit.Proofs) != len(circuit.Witnesses) {
+		return fmt.Errorf("AggregateCircuit must be built with NewCircuit")
+	}
+
+	verifier, err := stdgroth16.NewVerifier[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](api)
+	if err != nil {
+		return err
+	}
+	scalars, err := emulated.NewField[sw_bls12377.ScalarField](api)
+	if err != nil {
+		return err
+	}
+
+	Digest_mimc, _ := mimc.NewMiMC(api)
+	for i := range circuit.Proofs {
+		// the verifying key is made of constants, some of which can coincide
+		// in the multi-scalar multiplication of the public inputs
+		if err := verifier.AssertProof(circuit.VerifyingKey, circuit.Proofs[i], circuit.Witnesses[i], stdgroth16.WithCompleteArithmetic()); err != nil {
+			return err
+		}
+		// the public inputs are BLS12-377 scalars, which fit in the native
+		// field once reduced
+		for j := range circuit.Witnesses[i].Public {
+			bits := scalars.ToBitsCanonical(&circuit.Witnesses[i].Public[j])
+			Digest_mimc.Write(api.FromBinary(bits...))
+		}
+	}
+	api.AssertIsEqual(circuit.Digest, Digest_mimc.Sum())
+
+	return nil
+}
+
+// Digest returns the MiMC digest of the public inputs of the registrations, as
+// computed by AggregateCircuit
+func Digest(publics []witness.Witness) (fr.Element, error) {
+	h := mimc_bw6_761.NewMiMC()
+	for i := range publics {
+		vector, ok := publics[i].Vector().(bls12377_fr.Vector)
+		if !ok {
+			return fr.Element{}, fmt.Errorf("public witness %d is not defined on BLS12-377", i)
+		}
+		for j := range vector {
+			var e fr.Element
+			e.SetBigInt(vector[j].BigInt(new(big.Int)))
+			b := e.Bytes()
+			if _, err := h.Write(b[:]); err != nil {
+				return fr.Element{}, err
+			}
+		}
+	}
+	var res fr.Element
+	res.SetBytes(h.Sum(nil))
+	return res, nil
+}
+
+// Assign returns the assignment of the circuit aggregating the proofs, whose
+// public witnesses are publics
+func Assign(proofs []groth16.Proof, publics []witness.Witness) (*AggregateCircuit, error) {
+	if len(proofs) == 0 || len(proofs) != len(publics) {
+		return nil, fmt.Errorf("%d proofs for %d public witnesses", len(proofs), len(publics))
+	}
+	digest, err := Digest(publics)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &AggregateCircuit{
+		Digest:    digest.BigInt(new(big.Int)),
+		Proofs:    make([]Proof, len(proofs)),
+		Witnesses: make([]Witness, len(proofs)),
+	}
+	for i := range proofs {
+		if assignment.Proofs[i], err = stdgroth16.ValueOfProof[sw_bls12377.G1Affine, sw_bls12377.G2Affine](proofs[i]); err != nil {
+			return nil, err
+		}
+		if assignment.Witnesses[i], err = stdgroth16.ValueOfWitness[sw_bls12377.ScalarField](publics[i]); err != nil {
+			return nil, err
+		}
+	}
+	return assignment, nil
+}
@@ -0,0 +1,101 @@
+package aggregate
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377_fr "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12377 "github.com/consensys/gnark/backend/groth16/bls12-377"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bundle"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofreg"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/keys"
+	ppewitness "github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+func TestAggregate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs a groth16 setup of ProofReg")
+	}
+	// the range checks of ProofReg make its proofs carry commitments
+	reg, err := keys.New(keys.Curve, backend.GROTH16, t.TempDir(), "proofreg", "", new(proofreg.RegisterCircuit), keys.SRS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := reg.VK.(groth16.VerifyingKey)
+	s, err := ppewitness.RandomSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, err := s.ProofReg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bundle.Prove(reg, assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := b.Groth16Proof()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, ok := proof.(*groth16_bls12377.Proof); !ok || len(p.Commitments) == 0 {
+		t.Fatal("the ProofReg proof carries no commitment")
+	}
+
+	field := ecc.BW6_761.ScalarField()
+	circuit, err := NewCircuit(1, reg.CCS, vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggregated, err := Assign([]groth16.Proof{proof}, []witness.Witness{b.Public})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := Digest([]witness.Witness{b.Public})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregated.Digest.(*big.Int).Cmp(digest.BigInt(new(big.Int))) != 0 {
+		t.Fatal("the aggregate proof isn't assigned the digest of the registration")
+	}
+	if err := test.IsSolved(circuit, aggregated, field); err != nil {
+		t.Fatal(err)
+	}
+
+	// the digest of another registration is refused
+	var one fr.Element
+	one.SetOne()
+	digest.Add(&digest, &one)
+	aggregated.Digest = digest.BigInt(new(big.Int))
+	if err := test.IsSolved(circuit, aggregated, field); err == nil {
+		t.Fatal("the aggregate proof is solved with another digest")
+	}
+
+	// so is a public input changed after the proof, even with its digest
+	data, err := b.Public.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := witness.New(keys.Curve.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := public.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	public.Vector().(bls12377_fr.Vector)[0].SetOne()
+	if aggregated, err = Assign([]groth16.Proof{proof}, []witness.Witness{public}); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, aggregated, field); err == nil {
+		t.Fatal("the aggregate proof is solved with a tampered public input")
+	}
+}
@@ -0,0 +1,105 @@
+// Package proofdraw implements ProofDraw, proven by a participant withdrawing
+// from the auction: the note N_in registered in Cm_in is spent (Sn_in) and a
+// note N_out of the same value is created back to the participant in Cm_out.
+// The verifier checks that Cm_in is the commitment registered with the
+// ciphertext.
+package proofdraw
+
+import (
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// variable names must start with a capital letter
+type RegisterCircuit struct {
+	//public inputs
+	Cm_in      frontend.Variable            `gnark:",public"`
+	Cm_out     frontend.Variable            `gnark:",public"`
+	Sn_in      frontend.Variable            `gnark:",public"`
+	Nk_in_enc  frontend.Variable            `gnark:",public"`
+	Pk_out_enc frontend.Variable            `gnark:",public"`
+	B_enc      [bid.Steps]frontend.Variable `gnark:",public"`
+	Tag        frontend.Variable            `gnark:",public"`
+	G_r        twistededwards.Point         `gnark:",public"`
+	G          twistededwards.Point         `gnark:",public"`
+	G_b        twistededwards.Point         `gnark:",public"`
+
+	//secret inputs
+	N_in   note.Note
+	N_out  note.Note
+	Sk_in  frontend.Variable
+	G_d_in twistededwards.Point
+	B_i    [bid.Steps]frontend.Variable
+	G_r_b  twistededwards.Point
+	R      frontend.Variable
+}
+
+func (circuit *RegisterCircuit) Define(api frontend.API) error {
+
+	var G = circuit.G
+	// twisted Edwards curve of BLS12-377, defined over the field of the circuit
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BLS12_377)
+	if err != nil {
+		return err
+	}
+
+	//1) (nk_in||pk_out||b_0||b_1||...) == Dec(g_r_b, C, Tag), nk_in = H(sk_in, 1)
+	Nk_in := note.NullifierKey(api, circuit.Sk_in)
+	plaintext := aead.Decrypt(api, circuit.G_r_b, aead.NonceRegistration, circuit.ciphertext(), circuit.Tag)
+	api.AssertIsEqual(Nk_in, plaintext[0])
+	api.AssertIsEqual(circuit.N_out.Pk, plaintext[1])
+	for j := range circuit.B_i {
+		api.AssertIsEqual(circuit.B_i[j], plaintext[2+j])
+	}
+
+	//2) Cm_in == H(n_in.T, n_in.r, n_in.rho, n_in.Pk_in)
+	Cm_in := note.Commitment(api, circuit.N_in.T, circuit.N_in.R, circuit.N_in.Rho, circuit.N_in.Pk)
+	api.AssertIsEqual(circuit.Cm_in, Cm_in)
+
+	//	Pk_in == H(g_d_in, [ivk_in]g_d_in), ivk_in being derived from sk_in
+	Pk_in := note.KeyGen(api, curve, circuit.Sk_in, circuit.G_d_in)
+	api.AssertIsEqual(circuit.N_in.Pk, Pk_in)
+
+	//3) Sn_in = H(nk_in||N_in.rho)
+	Sn_in_computed := note.SerialNumber(api, Nk_in, circuit.N_in.Rho)
+	api.AssertIsEqual(circuit.Sn_in, Sn_in_computed)
+
+	//4) Cm_out == H(n_out.T, n_out.r, n_out.rho, n_out.Pk_out), N_out holding
+	//	the value of N_in
+	Cm_out := note.Commitment(api, circuit.N_out.T, circuit.N_out.R, circuit.N_out.Rho, circuit.N_out.Pk)
+	api.AssertIsEqual(circuit.Cm_out, Cm_out)
+	api.AssertIsEqual(circuit.N_out.T[0], circuit.N_in.T[0])
+	api.AssertIsEqual(circuit.N_out.T[1], circuit.N_in.T[1])
+
+	//	N_out.rho == H(sn_in, 0)
+	Rho_out := note.DeriveRho(api, 0, circuit.Sn_in)
+	api.AssertIsEqual(circuit.N_out.Rho, Rho_out)
+
+	//	the amounts of the notes are bounded and b is a well-formed bid
+	circuit.N_in.AssertValues(api)
+	if _, err := bid.Unpack(api, circuit.B_i); err != nil {
+		return err
+	}
+
+	//5) g_r == g^r
+	var G_r = curve.ScalarMul(G, circuit.R)
+	api.AssertIsEqual(circuit.G_r.X, G_r.X)
+	api.AssertIsEqual(circuit.G_r.Y, G_r.Y)
+
+	//6) g_r_b == (g^b)^r, which the auctioneer computes as (g^r)^b
+	G_r_b := curve.ScalarMul(circuit.G_b, circuit.R)
+	api.AssertIsEqual(circuit.G_r_b.X, G_r_b.X)
+	api.AssertIsEqual(circuit.G_r_b.Y, G_r_b.Y)
+
+	return nil
+}
+
+// ciphertext returns the encrypted nk_in, pk_out and steps of the bid
+func (circuit *RegisterCircuit) ciphertext() []frontend.Variable {
+	return append([]frontend.Variable{circuit.Nk_in_enc, circuit.Pk_out_enc}, circuit.B_enc[:]...)
+}
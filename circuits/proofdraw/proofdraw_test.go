@@ -0,0 +1,70 @@
+package proofdraw_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofdraw"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+func TestProofDraw(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	s, err := witness.RandomSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := s.ProofDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(&proofdraw.RegisterCircuit{}, valid, field); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := note.RandomNativeNote()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var one fr.Element
+	one.SetOne()
+	for name, tamper := range map[string]func(c *proofdraw.RegisterCircuit){
+		"another Cm_in": func(c *proofdraw.RegisterCircuit) {
+			c.Cm_in = note.Variable(other.Cm)
+		},
+		// the note opens Cm_in but isn't owned by sk_in
+		"another owner of N_in": func(c *proofdraw.RegisterCircuit) {
+			c.N_in.Pk = note.Variable(other.Pk)
+			c.Cm_in = note.Variable(note.NativeCommitment(s.N_in.T, s.N_in.R, s.N_in.Rho, other.Pk))
+		},
+		"another Cm_out": func(c *proofdraw.RegisterCircuit) {
+			c.Cm_out = note.Variable(other.Cm)
+		},
+		// N_out opens Cm_out but is worth more than N_in
+		"another value of N_out": func(c *proofdraw.RegisterCircuit) {
+			T := s.N_out.T
+			T[1].Add(&T[1], &one)
+			c.N_out.T[1] = note.Variable(T[1])
+			c.Cm_out = note.Variable(note.NativeCommitment(T, s.N_out.R, s.N_out.Rho, s.N_out.Pk))
+		},
+		"another Sn_in": func(c *proofdraw.RegisterCircuit) {
+			c.Sn_in = note.Variable(other.Rho)
+		},
+		"another tag": func(c *proofdraw.RegisterCircuit) {
+			c.Tag = 1
+		},
+	} {
+		c, err := s.ProofDraw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tamper(c)
+		if err := test.IsSolved(&proofdraw.RegisterCircuit{}, c, field); err == nil {
+			t.Errorf("%s is accepted", name)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package prooff implements ProofF, proven by the auctioneer once the auction
+// is computed: the bids of the buyers and sellers are decrypted, the note of
+// each participant is spent, the note resulting from the auction is committed
+// in Cm_out, and the published volume, and the quantity and payment of each
+// participant, are the clearing of the decrypted bids under the pricing rule
+// of the circuit. The clearing price of each side, paid by every buyer and
+// received by every seller, is published as well, zero under pay-as-bid where
+// each bid trades at its own price.
+//
+// The note spent by each participant is the one registered by ProofReg: its
+// opening matches the public Cm_in, which the verifier checks to be the
+// commitment registered with the ciphertext of the bidder. The note created
+// holds the value of the note spent, less the payment of a buyer or plus the
+// payment of a seller, all the notes of a round being in the public Asset.
+//
+// A round trades energy for a single delivery slot: every buyer bids on the
+// buy side and every seller on the sell side, for the public Slot, so that
+// bids for different delivery hours are never crossed. The curves of the
+// participants are aggregated into the curves of the market, each step of a
+// curve being a bid of the auction.
+//
+// The key g_r_b == (g^r)^b of each bidder is public and checked out of the
+// circuit: the secret key b of the auctioneer may be shared by a committee,
+// whose members publish their shares of g_r_b with proofs (see package
+// auctioneer), so that no one holds b to prove it in-circuit.
+package prooff
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/auction"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// Bidder holds the values of one participant
+type Bidder struct {
+	//public inputs
+	Cm_in      frontend.Variable            `gnark:",public"`
+	Cm_out     frontend.Variable            `gnark:",public"`
+	Sn_in      frontend.Variable            `gnark:",public"`
+	Nk_in_enc  frontend.Variable            `gnark:",public"`
+	Pk_out_enc frontend.Variable            `gnark:",public"`
+	B_enc      [bid.Steps]frontend.Variable `gnark:",public"`
+	Tag        frontend.Variable            `gnark:",public"`
+	G_r        twistededwards.Point         `gnark:",public"`
+	G_r_b      twistededwards.Point         `gnark:",public"`
+	// quantity traded by the bidder
+	Allocation frontend.Variable `gnark:",public"`
+	// amount paid or received by the bidder
+	Payment frontend.Variable `gnark:",public"`
+
+	//secret inputs
+	N_in  note.Note
+	N_out note.Note
+	B_i   [bid.Steps]frontend.Variable
+	// quantity traded and price of each unit for each step of the bid
+	Allocations [bid.Steps]frontend.Variable
+	Prices      [bid.Steps]frontend.Variable
+}
+
+// variable names must start with a capital letter
+type RegisterCircuit struct {
+	//public inputs
+	Volume frontend.Variable `gnark:",public"`
+	// clearing price of each side, zero when no unit trades
+	Buy_price  frontend.Variable    `gnark:",public"`
+	Sell_price frontend.Variable    `gnark:",public"`
+	G          twistededwards.Point `gnark:",public"`
+	G_b        twistededwards.Point `gnark:",public"`
+	// delivery slot of every bid of the round
+	Slot frontend.Variable `gnark:",public"`
+	// asset of the notes of the round, in which the payments are made
+	Asset frontend.Variable `gnark:",public"`
+
+	Buyers  []Bidder
+	Sellers []Bidder
+
+	// pricing rule of the auction
+	Rule auction.Rule `gnark:"-"`
+}
+
+// NewCircuit returns a ProofF circuit clearing the bids of the given number of
+// buyers and sellers under the rule
+func NewCircuit(buyers, sellers int, rule auction.Rule) *RegisterCircuit {
+	return &RegisterCircuit{
+		Buyers:  make([]Bidder, buyers),
+		Sellers: make([]Bidder, sellers),
+		Rule:    rule,
+	}
+}
+
+// Shape returns the number of buyers and sellers of the circuit
+func (circuit *RegisterCircuit) Shape() (buyers, sellers int) {
+	return len(circuit.Buyers), len(circuit.Sellers)
+}
+
+func (circuit *RegisterCircuit) Define(api frontend.API) error {
+
+	var buyers, sellers = circuit.Shape()
+	if buyers == 0 || sellers == 0 || circuit.Rule == nil {
+		return fmt.Errorf("ProofF must be built with NewCircuit")
+	}
+
+	//1) decrypt the bids, spend the notes and commit to the new ones
+	var Buys, Sells []auction.Bid
+	for i := range circuit.Buyers {
+		steps, err := circuit.define(api, &circuit.Buyers[i], bid.Buy)
+		if err != nil {
+			return err
+		}
+		Buys = append(Buys, steps...)
+	}
+	for j := range circuit.Sellers {
+		steps, err := circuit.define(api, &circuit.Sellers[j], bid.Sell)
+		if err != nil {
+			return err
+		}
+		Sells = append(Sells, steps...)
+	}
+
+	//2) compute the auction
+	return auction.VerifyClearing(api, circuit.Rule, circuit.Volume, circuit.Buy_price, circuit.Sell_price, Buys, Sells)
+}
+
+// define checks the bidder, that its bid is on the side for the slot of the
+// round and that its new note is settled, and returns the steps of its curve
+// with their outcome, whose totals are published
+func (circuit *RegisterCircuit) define(api frontend.API, bidder *Bidder, side int) ([]auction.Bid, error) {
+	bidder.define(api)
+	b, err := bid.Unpack(api, bidder.B_i)
+	if err != nil {
+		return nil, err
+	}
+	api.AssertIsEqual(b.Side, side)
+	api.AssertIsEqual(b.Slot, circuit.Slot)
+
+	steps := make([]auction.Bid, len(b.Steps))
+	var allocation, payment frontend.Variable = 0, 0
+	for j, step := range b.Steps {
+		steps[j] = auction.Bid{Value: step.Price, Quantity: step.Quantity, Allocation: bidder.Allocations[j], Price: bidder.Prices[j]}
+		allocation = api.Add(allocation, bidder.Allocations[j])
+		payment = api.Add(payment, api.Mul(bidder.Allocations[j], bidder.Prices[j]))
+	}
+	api.AssertIsEqual(bidder.Allocation, allocation)
+	api.AssertIsEqual(bidder.Payment, payment)
+
+	//	N_out.T == (asset, N_in.T[1] - payment) for a buyer and
+	//	(asset, N_in.T[1] + payment) for a seller, N_out.T[1] being range
+	//	checked so that a buyer can't pay more than N_in holds
+	api.AssertIsEqual(bidder.N_in.T[0], circuit.Asset)
+	api.AssertIsEqual(bidder.N_out.T[0], circuit.Asset)
+	value := api.Add(bidder.N_in.T[1], bidder.Payment)
+	if side == bid.Buy {
+		value = api.Sub(bidder.N_in.T[1], bidder.Payment)
+	}
+	api.AssertIsEqual(bidder.N_out.T[1], value)
+	return steps, nil
+}
+
+// define checks the ciphertext, the note spent, the serial number and the new
+// commitment of the bidder
+func (bidder *Bidder) define(api frontend.API) {
+
+	//1) (nk_in||pk_out||b_0||b_1||...) == Dec(g_r_b, C, Tag)
+	plaintext := aead.Decrypt(api, bidder.G_r_b, aead.NonceRegistration, bidder.ciphertext(), bidder.Tag)
+	Nk_in_computed := plaintext[0]
+	api.AssertIsEqual(bidder.N_out.Pk, plaintext[1])
+	for j := range bidder.B_i {
+		api.AssertIsEqual(bidder.B_i[j], plaintext[2+j])
+	}
+
+	//2) Cm_in == H(n_in.T, n_in.r, n_in.rho, n_in.Pk_in), the note registered
+	Cm_in_computed := note.Commitment(api, bidder.N_in.T, bidder.N_in.R, bidder.N_in.Rho, bidder.N_in.Pk)
+	api.AssertIsEqual(bidder.Cm_in, Cm_in_computed)
+
+	//3) compute Sn
+	Sn_computed := note.SerialNumber(api, Nk_in_computed, bidder.N_in.Rho)
+	api.AssertIsEqual(bidder.Sn_in, Sn_computed)
+
+	//	N_out.rho == H(sn_in, 0)
+	Rho_out := note.DeriveRho(api, 0, bidder.Sn_in)
+	api.AssertIsEqual(bidder.N_out.Rho, Rho_out)
+
+	//4) Compute cm_out
+	Cm_out_computed := note.Commitment(api, bidder.N_out.T, bidder.N_out.R, bidder.N_out.Rho, bidder.N_out.Pk)
+	api.AssertIsEqual(bidder.Cm_out, Cm_out_computed)
+
+	//	the amounts of the notes are bounded
+	bidder.N_in.AssertValues(api)
+	bidder.N_out.AssertValues(api)
+}
+
+// ciphertext returns the encrypted nk_in, pk_out and steps of the bid
+func (bidder *Bidder) ciphertext() []frontend.Variable {
+	return append([]frontend.Variable{bidder.Nk_in_enc, bidder.Pk_out_enc}, bidder.B_enc[:]...)
+}
@@ -0,0 +1,90 @@
+// Package proofreg implements ProofReg, proven by a participant registering a
+// bid: the note N_in is committed in Cm_in and (nk_in, pk_out, b) are
+// encrypted under g_r_b (see package aead) so that only the auctioneer can read
+// them. The nullifier key nk_in lets the auctioneer spend N_in in ProofF, while
+// the spending key sk_in stays with the participant. b is the bid packed by
+// package bid, one element per step of its curve.
+package proofreg
+
+import (
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// variable names must start with a capital letter
+type RegisterCircuit struct {
+	//public inputs
+	Cm_in      frontend.Variable            `gnark:",public"`
+	Nk_in_enc  frontend.Variable            `gnark:",public"`
+	Pk_out_enc frontend.Variable            `gnark:",public"`
+	B_enc      [bid.Steps]frontend.Variable `gnark:",public"`
+	Tag        frontend.Variable            `gnark:",public"`
+	G_r        twistededwards.Point         `gnark:",public"`
+	G          twistededwards.Point         `gnark:",public"`
+	G_b        twistededwards.Point         `gnark:",public"`
+
+	//secret inputs
+	N_in   note.Note
+	Sk_in  frontend.Variable
+	G_d_in twistededwards.Point
+	B_i    [bid.Steps]frontend.Variable
+	G_r_b  twistededwards.Point
+	Pk_out frontend.Variable
+	R      frontend.Variable
+}
+
+func (circuit *RegisterCircuit) Define(api frontend.API) error {
+
+	var G = circuit.G
+	// twisted Edwards curve of BLS12-377, defined over the field of the circuit
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BLS12_377)
+	if err != nil {
+		return err
+	}
+
+	//1) (C, Tag) == Enc(g_r_b, nk_in||pk_out||b_0||b_1||...), nk_in = H(sk_in, 1)
+	Nk_in := note.NullifierKey(api, circuit.Sk_in)
+	plaintext := append([]frontend.Variable{Nk_in, circuit.Pk_out}, circuit.B_i[:]...)
+	C, Tag := aead.Encrypt(api, circuit.G_r_b, aead.NonceRegistration, plaintext)
+	for i, c := range circuit.ciphertext() {
+		api.AssertIsEqual(c, C[i])
+	}
+	api.AssertIsEqual(circuit.Tag, Tag)
+
+	//2) Cm_in == H(n_in.T, n_in.r, n_in.rho, n_in.Pk_in)
+	Cm_in := note.Commitment(api, circuit.N_in.T, circuit.N_in.R, circuit.N_in.Rho, circuit.N_in.Pk)
+	api.AssertIsEqual(circuit.Cm_in, Cm_in)
+
+	//3) Pk_in == H(g_d_in, [ivk_in]g_d_in), ivk_in being derived from sk_in
+	Pk_in := note.KeyGen(api, curve, circuit.Sk_in, circuit.G_d_in)
+	api.AssertIsEqual(circuit.N_in.Pk, Pk_in)
+
+	//	the amounts of the note are bounded and b is a well-formed bid
+	circuit.N_in.AssertValues(api)
+	if _, err := bid.Unpack(api, circuit.B_i); err != nil {
+		return err
+	}
+
+	//4) g_r == g^r
+	var G_r = curve.ScalarMul(G, circuit.R)
+	api.AssertIsEqual(circuit.G_r.X, G_r.X)
+	api.AssertIsEqual(circuit.G_r.Y, G_r.Y)
+
+	//5) g_r_b == (g^b)^r, which the auctioneer computes as (g^r)^b
+	G_r_b := curve.ScalarMul(circuit.G_b, circuit.R)
+	api.AssertIsEqual(circuit.G_r_b.X, G_r_b.X)
+	api.AssertIsEqual(circuit.G_r_b.Y, G_r_b.Y)
+
+	return nil
+
+}
+
+// ciphertext returns the encrypted nk_in, pk_out and steps of the bid
+func (circuit *RegisterCircuit) ciphertext() []frontend.Variable {
+	return append([]frontend.Variable{circuit.Nk_in_enc, circuit.Pk_out_enc}, circuit.B_enc[:]...)
+}
@@ -0,0 +1,63 @@
+package proofreg_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofreg"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+func TestProofReg(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	s, err := witness.RandomSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := s.ProofReg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(&proofreg.RegisterCircuit{}, valid, field); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := note.RandomNativeNote()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tamper := range map[string]func(c *proofreg.RegisterCircuit){
+		"another Cm_in": func(c *proofreg.RegisterCircuit) {
+			c.Cm_in = note.Variable(other.Cm)
+		},
+		// the note opens Cm_in but isn't owned by sk_in
+		"another owner of N_in": func(c *proofreg.RegisterCircuit) {
+			c.N_in.Pk = note.Variable(other.Pk)
+			c.Cm_in = note.Variable(note.NativeCommitment(s.N_in.T, s.N_in.R, s.N_in.Rho, other.Pk))
+		},
+		"another ciphertext": func(c *proofreg.RegisterCircuit) {
+			c.B_enc[2] = 1
+		},
+		"another pk_out": func(c *proofreg.RegisterCircuit) {
+			c.Pk_out = note.Variable(other.Pk)
+		},
+		"another g_r": func(c *proofreg.RegisterCircuit) {
+			c.G_r = c.G
+		},
+		"another g_r_b": func(c *proofreg.RegisterCircuit) {
+			c.G_r_b = c.G_b
+		},
+	} {
+		c, err := s.ProofReg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tamper(c)
+		if err := test.IsSolved(&proofreg.RegisterCircuit{}, c, field); err == nil {
+			t.Errorf("%s is accepted", name)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package prooftx implements ProofTx, the transfer of notes between
+// participants: l old notes are spent, m new ones are created and the balance
+// of each asset is preserved, without revealing the assets moved. The public
+// value V_pub_in enters the transaction and V_pub_out leaves it, both of asset
+// Asset_pub, together with the fee paid to the operator in that asset:
+//
+//	sum_in + V_pub_in == sum_out + V_pub_out + Fee
+package prooftx
+
+import (
+	"fmt"
+
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/merkle"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// variable names must start with a capital letter
+type RegisterCircuit struct {
+	//public inputs
+	Rt              frontend.Variable    `gnark:",public"`
+	Sn_old_list     []frontend.Variable  `gnark:",public"`
+	Cm_new_list     []frontend.Variable  `gnark:",public"`
+	Nk_in_enc_list  []frontend.Variable  `gnark:",public"`
+	Pk_out_enc_list []frontend.Variable  `gnark:",public"`
+	B_enc           frontend.Variable    `gnark:",public"`
+	Tag             frontend.Variable    `gnark:",public"`
+	G_r             twistededwards.Point `gnark:",public"`
+	G               twistededwards.Point `gnark:",public"`
+	G_b             twistededwards.Point `gnark:",public"`
+	Asset_pub       frontend.Variable    `gnark:",public"`
+	V_pub_in        frontend.Variable    `gnark:",public"`
+	V_pub_out       frontend.Variable    `gnark:",public"`
+	Fee             frontend.Variable    `gnark:",public"`
+
+	//secret inputs
+	Path_list     [][]frontend.Variable
+	Siblings_list [][]frontend.Variable
+	N_old_list    []note.NoteFull
+	N_new_list    []note.NoteFull
+	B_i           frontend.Variable
+	G_r_b         twistededwards.Point
+	R             frontend.Variable
+}
+
+// NewCircuit returns a ProofTx circuit spending l notes, whose commitments are
+// leaves of a merkle tree of depth h, and creating m notes
+func NewCircuit(l, m, h int) *RegisterCircuit {
+	circuit := &RegisterCircuit{
+		Sn_old_list:     make([]frontend.Variable, l),
+		Cm_new_list:     make([]frontend.Variable, m),
+		Nk_in_enc_list:  make([]frontend.Variable, l),
+		Pk_out_enc_list: make([]frontend.Variable, m),
+		Path_list:       make([][]frontend.Variable, l),
+		Siblings_list:   make([][]frontend.Variable, l),
+		N_old_list:      make([]note.NoteFull, l),
+		N_new_list:      make([]note.NoteFull, m),
+	}
+	for i := range circuit.Path_list {
+		circuit.Path_list[i] = make([]frontend.Variable, h)
+		circuit.Siblings_list[i] = make([]frontend.Variable, h)
+	}
+	return circuit
+}
+
+// Shape returns the number of notes spent and created and the merkle tree
+// depth of the circuit
+func (circuit *RegisterCircuit) Shape() (l, m, h int) {
+	l, m = len(circuit.N_old_list), len(circuit.N_new_list)
+	if len(circuit.Path_list) > 0 {
+		h = len(circuit.Path_list[0])
+	}
+	return l, m, h
+}
+
+func (circuit *RegisterCircuit) Define(api frontend.API) error {
+
+	var l, m, h = circuit.Shape()
+	if l == 0 || m == 0 || h == 0 {
+		return fmt.Errorf("ProofTx must be built with NewCircuit")
+	}
+
+	// twisted Edwards curve of BLS12-377, defined over the field of the circuit
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BLS12_377)
+	if err != nil {
+		return err
+	}
+
+	////////
+	// Start of Transfert subroutine
+	////////
+
+	//Compute nk_old = H(sk_old, 1) and sn_old = H(nk_old, rho_old)
+	Nk_old := make([]frontend.Variable, l)
+	for i := 0; i < l; i++ {
+		Nk_old[i] = note.NullifierKey(api, circuit.N_old_list[i].Sk)
+		Sn_in_computed := note.SerialNumber(api, Nk_old[i], circuit.N_old_list[i].Rho)
+		api.AssertIsEqual(circuit.Sn_old_list[i], Sn_in_computed)
+	}
+
+	// a note spent twice would count its value twice in the balance
+	for i := 0; i < l; i++ {
+		for k := i + 1; k < l; k++ {
+			api.AssertIsDifferent(circuit.Sn_old_list[i], circuit.Sn_old_list[k])
+		}
+	}
+
+	//Compute Rho_new_j = H(sn_old_1, ..., sn_old_l, j)
+	for j := 0; j < m; j++ {
+		Rho_new := note.DeriveRho(api, j, circuit.Sn_old_list...)
+		api.AssertIsEqual(circuit.N_new_list[j].Rho, Rho_new)
+	}
+
+	//compute cm_new_list
+	for j := 0; j < m; j++ {
+		Cm_new := circuit.N_new_list[j].Commit(api)
+		api.AssertIsEqual(circuit.Cm_new_list[j], Cm_new)
+	}
+
+	//encrypt
+	//(C, Tag) == Enc(g_r_b, nk_old_1||...||nk_old_l||pk_new_1||...||pk_new_m||b)
+	plaintext := make([]frontend.Variable, 0, l+m+1)
+	plaintext = append(plaintext, Nk_old...)
+	for j := 0; j < m; j++ {
+		plaintext = append(plaintext, circuit.N_new_list[j].Pk)
+	}
+	plaintext = append(plaintext, circuit.B_i)
+	C, Tag := aead.Encrypt(api, circuit.G_r_b, aead.NonceTransfer, plaintext)
+	for i := 0; i < l; i++ {
+		api.AssertIsEqual(circuit.Nk_in_enc_list[i], C[i])
+	}
+	for j := 0; j < m; j++ {
+		api.AssertIsEqual(circuit.Pk_out_enc_list[j], C[l+j])
+	}
+	api.AssertIsEqual(circuit.B_enc, C[l+m])
+	api.AssertIsEqual(circuit.Tag, Tag)
+
+	////////
+	// End of Transfert subroutine
+	////////
+
+	////////
+	// check if the balance of each asset is preserved
+	////////
+
+	// the amounts are bounded, so that the sums can't wrap around the modulus
+	for i := 0; i < l; i++ {
+		circuit.N_old_list[i].AssertValues(api)
+	}
+	for j := 0; j < m; j++ {
+		circuit.N_new_list[j].AssertValues(api)
+	}
+	note.AssertIsValue(api, circuit.B_i, circuit.V_pub_in, circuit.V_pub_out, circuit.Fee)
+
+	// the public values count as a note spent and two notes created of asset
+	// Asset_pub
+	in := make([][2]frontend.Variable, 0, l+1)
+	for i := 0; i < l; i++ {
+		in = append(in, circuit.N_old_list[i].T)
+	}
+	in = append(in, [2]frontend.Variable{circuit.Asset_pub, circuit.V_pub_in})
+	out := make([][2]frontend.Variable, 0, m+2)
+	for j := 0; j < m; j++ {
+		out = append(out, circuit.N_new_list[j].T)
+	}
+	out = append(out, [2]frontend.Variable{circuit.Asset_pub, circuit.V_pub_out}, [2]frontend.Variable{circuit.Asset_pub, circuit.Fee})
+	assertConservation(api, in, out)
+
+	////////
+	// Check merkle proof: cm_old_i is a leaf of the tree of root rt
+	////////
+
+	for i := 0; i < l; i++ {
+		if len(circuit.Path_list[i]) != h || len(circuit.Siblings_list[i]) != h {
+			return fmt.Errorf("ProofTx must be built with NewCircuit")
+		}
+		Cm_old := circuit.N_old_list[i].Commit(api)
+		merkle.VerifyPath(api, circuit.Rt, Cm_old, circuit.Path_list[i], circuit.Siblings_list[i])
+	}
+
+	////////
+	// ensure Pk == H(g_d, [ivk]g_d), ivk being derived from sk
+	////////
+	for i := 0; i < l; i++ {
+		circuit.N_old_list[i].AssertOwner(api, curve)
+	}
+
+	////////
+	// ensure g_r == g^r
+	////////
+
+	//4)
+	var G = circuit.G
+	var G_r = curve.ScalarMul(G, circuit.R)
+	api.AssertIsEqual(circuit.G_r.X, G_r.X)
+	api.AssertIsEqual(circuit.G_r.Y, G_r.Y)
+
+	////////
+	// ensure g_r_b == (g^b)^r
+	////////
+
+	//5) g_r_b == (g^b)^r, which the auctioneer computes as (g^r)^b
+	G_r_b := curve.ScalarMul(circuit.G_b, circuit.R)
+	api.AssertIsEqual(circuit.G_r_b.X, G_r_b.X)
+	api.AssertIsEqual(circuit.G_r_b.Y, G_r_b.Y)
+
+	return nil
+
+}
+
+// assertConservation asserts that, for the asset T[0] of each input in[i],
+// the values T[1] of the inputs and of the outputs of that asset sum up to the
+// same amount, and that every output is of the asset of some input. The assets
+// are only compared with each other, so they stay secret.
+func assertConservation(api frontend.API, in, out [][2]frontend.Variable) {
+	l, m := len(in), len(out)
+
+	// same[i][k] == 1 iff the inputs i and k share their asset, and
+	// sameOut[i][j] == 1 iff the input i and the output j do
+	same := make([][]frontend.Variable, l)
+	sameOut := make([][]frontend.Variable, l)
+	for i := 0; i < l; i++ {
+		same[i] = make([]frontend.Variable, l)
+		sameOut[i] = make([]frontend.Variable, m)
+		for k := 0; k < i; k++ {
+			same[i][k] = api.IsZero(api.Sub(in[i][0], in[k][0]))
+			same[k][i] = same[i][k]
+		}
+		same[i][i] = 1
+		for j := 0; j < m; j++ {
+			sameOut[i][j] = api.IsZero(api.Sub(in[i][0], out[j][0]))
+		}
+	}
+
+	for i := 0; i < l; i++ {
+		var left_sum frontend.Variable = frontend.Variable(0)
+		for k := 0; k < l; k++ {
+			left_sum = api.Add(left_sum, api.Mul(same[i][k], in[k][1]))
+		}
+		var right_sum frontend.Variable = frontend.Variable(0)
+		for j := 0; j < m; j++ {
+			right_sum = api.Add(right_sum, api.Mul(sameOut[i][j], out[j][1]))
+		}
+		// the value of the asset transferred is itself an amount
+		note.AssertIsValue(api, left_sum)
+		api.AssertIsEqual(left_sum, right_sum)
+	}
+
+	// an output of an asset that isn't spent would create value out of nothing
+	for j := 0; j < m; j++ {
+		var matches frontend.Variable = frontend.Variable(0)
+		for i := 0; i < l; i++ {
+			matches = api.Add(matches, sameOut[i][j])
+		}
+		api.AssertIsDifferent(matches, 0)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bundle"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/aggregate"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofreg"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/keys"
+)
+
+// aggregateName is the identifier of the aggregate circuit in the keys and the
+// proof bundles
+const aggregateName = "aggregate"
+
+// registration returns the groth16 setup of ProofReg, whose proofs are
+// aggregated
+func registration(keysDir string) (*keys.Setup, groth16.VerifyingKey, error) {
+	s, err := keys.Load(keys.Curve, backend.GROTH16, keysDir, "proofreg", "", new(proofreg.RegisterCircuit))
+	if err != nil {
+		return nil, nil, err
+	}
+	vk, ok := s.VK.(groth16.VerifyingKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected verifying key %T", s.VK)
+	}
+	return s, vk, nil
+}
+
+// aggregateCircuit returns the aggregate circuit of the parameters
+func aggregateCircuit(keysDir, params string) (*aggregate.AggregateCircuit, error) {
+	var n int
+	if _, err := fmt.Sscanf(params, "n=%d", &n); err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid aggregate parameters %q", params)
+	}
+	reg, vk, err := registration(keysDir)
+	if err != nil {
+		return nil, err
+	}
+	return aggregate.NewCircuit(n, reg.CCS, vk)
+}
+
+func aggregateProofs(args []string) error {
+	var keysDir, proofsList, proofPath string
+	fs := newFlagSet("aggregate", nil, &keysDir)
+	fs.StringVar(&proofsList, "proofs", "", "comma separated groth16 ProofReg bundles")
+	fs.StringVar(&proofPath, "proof", "", "file the aggregate proof bundle is written to, in JSON if it ends with .json")
+	if _, err := parse(fs, args, nil); err != nil {
+		return err
+	}
+	if proofsList == "" || proofPath == "" {
+		return usageError{"--proofs and --proof are required"}
+	}
+
+	reg, vk, err := registration(keysDir)
+	if err != nil {
+		return err
+	}
+	paths := strings.Split(proofsList, ",")
+	proofs := make([]groth16.Proof, len(paths))
+	publics := make([]witness.Witness, len(paths))
+	for i, path := range paths {
+		b, err := bundle.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := b.Check(reg.Header, reg.VK); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		// a proof that doesn't verify would leave the aggregate circuit
+		// unsatisfied, reject it here to name it
+		if err := b.Verify(reg.Header, reg.VK); err != nil {
+			return fmt.Errorf("%w: %s: %v", errRejected, path, err)
+		}
+		if proofs[i], err = b.Groth16Proof(); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		publics[i] = b.Public
+	}
+
+	params := fmt.Sprintf("n=%d", len(paths))
+	circuit, err := aggregate.NewCircuit(len(paths), reg.CCS, vk)
+	if err != nil {
+		return err
+	}
+	setup, err := keys.New(aggregate.Curve, backend.GROTH16, keysDir, aggregateName, params, circuit, keys.SRS{})
+	if err != nil {
+		return err
+	}
+	assignment, err := aggregate.Assign(proofs, publics)
+	if err != nil {
+		return err
+	}
+	proof, err := bundle.Prove(setup, assignment)
+	if err != nil {
+		return err
+	}
+	if err := proof.WriteFile(proofPath); err != nil {
+		return err
+	}
+	fmt.Printf("digest %v\n", assignment.Digest)
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/auction"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofdraw"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooff"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofreg"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooftx"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// shape holds the parameters given on the command line
+type shape struct {
+	l, m, h         int
+	buyers, sellers int
+	rule            string
+}
+
+// circuitSpec describes how the command line builds a circuit
+type circuitSpec struct {
+	// identifier written in the keys and the proof bundles
+	name string
+	// params returns the parameters of the circuit of the given shape
+	params func(s shape) string
+	// circuit returns the circuit to compile for the parameters
+	circuit func(params string) (frontend.Circuit, error)
+	// assign reads the secrets and returns the assignment with its parameters
+	assign func(r io.Reader) (frontend.Circuit, string, error)
+	// random draws secrets of the given shape and writes them
+	random func(w io.Writer, s shape) error
+}
+
+// circuitSpecs are indexed by the name given to --circuit
+var circuitSpecs = map[string]*circuitSpec{
+	"reg":  participantSpec("proofreg", func() frontend.Circuit { return new(proofreg.RegisterCircuit) }, assignFunc((*witness.Secrets).ProofReg)),
+	"draw": participantSpec("proofdraw", func() frontend.Circuit { return new(proofdraw.RegisterCircuit) }, assignFunc((*witness.Secrets).ProofDraw)),
+	"f": {
+		name: "prooff",
+		params: func(s shape) string {
+			return fmt.Sprintf("buyers=%d,sellers=%d,rule=%s", s.buyers, s.sellers, s.rule)
+		},
+		circuit: func(params string) (frontend.Circuit, error) {
+			var s shape
+			if _, err := fmt.Sscanf(params, "buyers=%d,sellers=%d,rule=%s", &s.buyers, &s.sellers, &s.rule); err != nil {
+				return nil, fmt.Errorf("invalid prooff parameters %q", params)
+			}
+			if s.buyers < 1 || s.sellers < 1 {
+				return nil, fmt.Errorf("invalid prooff parameters %q", params)
+			}
+			rule, err := auction.ParseRule(s.rule)
+			if err != nil {
+				return nil, err
+			}
+			return prooff.NewCircuit(s.buyers, s.sellers, rule), nil
+		},
+		assign: func(r io.Reader) (frontend.Circuit, string, error) {
+			round, err := witness.ReadRound(r)
+			if err != nil {
+				return nil, "", err
+			}
+			assignment, err := round.ProofF()
+			if err != nil {
+				return nil, "", err
+			}
+			buyers, sellers := assignment.Shape()
+			return assignment, fmt.Sprintf("buyers=%d,sellers=%d,rule=%s", buyers, sellers, assignment.Rule.Name()), nil
+		},
+		random: func(w io.Writer, s shape) error {
+			round, err := witness.RandomRound(s.buyers, s.sellers, s.rule)
+			if err != nil {
+				return err
+			}
+			return round.WriteJSON(w)
+		},
+	},
+	"tx": {
+		name: "prooftx",
+		params: func(s shape) string {
+			return fmt.Sprintf("l=%d,m=%d,h=%d", s.l, s.m, s.h)
+		},
+		circuit: func(params string) (frontend.Circuit, error) {
+			var s shape
+			if _, err := fmt.Sscanf(params, "l=%d,m=%d,h=%d", &s.l, &s.m, &s.h); err != nil {
+				return nil, fmt.Errorf("invalid prooftx parameters %q", params)
+			}
+			if s.l < 1 || s.m < 1 || s.h < 1 {
+				return nil, fmt.Errorf("invalid prooftx parameters %q", params)
+			}
+			return prooftx.NewCircuit(s.l, s.m, s.h), nil
+		},
+		assign: func(r io.Reader) (frontend.Circuit, string, error) {
+			t, err := witness.ReadTransfer(r)
+			if err != nil {
+				return nil, "", err
+			}
+			assignment, err := t.ProofTx()
+			if err != nil {
+				return nil, "", err
+			}
+			l, m, h := assignment.Shape()
+			return assignment, fmt.Sprintf("l=%d,m=%d,h=%d", l, m, h), nil
+		},
+		random: func(w io.Writer, s shape) error {
+			t, err := witness.RandomTransfer(s.l, s.m, s.h)
+			if err != nil {
+				return err
+			}
+			return t.WriteJSON(w)
+		},
+	},
+}
+
+// participantSpec describes the circuits proven from the secrets of a
+// participant, which have no parameters
+func participantSpec(name string, circuit func() frontend.Circuit, assign func(*witness.Secrets) (frontend.Circuit, error)) *circuitSpec {
+	return &circuitSpec{
+		name:   name,
+		params: func(shape) string { return "" },
+		circuit: func(params string) (frontend.Circuit, error) {
+			if params != "" {
+				return nil, fmt.Errorf("%s has no parameters, got %q", name, params)
+			}
+			return circuit(), nil
+		},
+		assign: func(r io.Reader) (frontend.Circuit, string, error) {
+			s, err := witness.ReadSecrets(r)
+			if err != nil {
+				return nil, "", err
+			}
+			assignment, err := assign(&s)
+			return assignment, "", err
+		},
+		random: func(w io.Writer, _ shape) error {
+			s, err := witness.RandomSecrets()
+			if err != nil {
+				return err
+			}
+			return s.WriteJSON(w)
+		},
+	}
+}
+
+// assignFunc adapts a witness builder to the circuitSpec signature
+func assignFunc[C frontend.Circuit](build func(*witness.Secrets) (C, error)) func(*witness.Secrets) (frontend.Circuit, error) {
+	return func(s *witness.Secrets) (frontend.Circuit, error) {
+		assignment, err := build(s)
+		if err != nil {
+			return nil, err
+		}
+		return assignment, nil
+	}
+}
+
+// specByName returns the spec of the circuit identifier found in the keys and
+// the proof bundles
+func specByName(name string) (*circuitSpec, error) {
+	for _, spec := range circuitSpecs {
+		if spec.name == name {
+			return spec, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown circuit %q", name)
+}
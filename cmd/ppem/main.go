@@ -0,0 +1,327 @@
+// Command ppem runs the setup, proves and verifies the circuits of the
+// mechanism without editing any source:
+//
+//	ppem setup   --circuit reg|draw|f|tx [--backend groth16|plonk] [--srs srs.bin [--srs-lagrange l.bin] | --unsafe-srs] [--keys dir] [-l 8 -m 8 -h 3] [--buyers 4 --sellers 4 --rule uniform]
+//	ppem witness --circuit reg|draw|f|tx [-l 8 -m 8 -h 3] [--buyers 4 --sellers 4 --rule uniform] [--out w.json]
+//	ppem prove   --circuit reg|draw|f|tx [--backend groth16|plonk] --witness w.json --proof p.bin [--keys dir]
+//	ppem verify  --proof p.bin [--keys dir]
+//	ppem aggregate --proofs a.bin,b.bin,... --proof agg.bin [--keys dir]
+//
+// setup compiles the circuit and generates its keys, unless they already
+// exist. The PlonK keys derive from the canonical KZG SRS of a ceremony given
+// with --srs, or, with --unsafe-srs and for tests only, from an SRS generated
+// locally whose secret is known. witness draws random secrets, in the JSON format read by prove. prove
+// writes a proof bundle, in JSON if the file ends with .json. verify checks a
+// bundle against the keys of the circuit and backend it names. aggregate
+// verifies groth16 ProofReg proofs inside one proof on BW6-761, whose public
+// input is the digest of their public inputs, and prints the digest.
+//
+// The exit code is 0 on success, 1 when the proof is rejected, 2 on a usage
+// error and 3 on any other error (missing files, keys of another circuit,
+// invalid witness...).
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/logger"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bundle"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/aggregate"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/keys"
+)
+
+// exit codes
+const (
+	exitOK       = 0
+	exitRejected = 1
+	exitUsage    = 2
+	exitError    = 3
+)
+
+// errRejected is returned when a proof doesn't verify
+var errRejected = errors.New("proof rejected")
+
+// usageError is returned on invalid arguments
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+var commands = map[string]func(args []string) error{
+	"setup":     setup,
+	"witness":   randomWitness,
+	"prove":     prove,
+	"verify":    verify,
+	"aggregate": aggregateProofs,
+}
+
+func main() {
+	// the output is read by scripts
+	logger.Disable()
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executes the command and returns the exit code
+func run(args []string) int {
+	if len(args) == 0 || commands[args[0]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: ppem setup|witness|prove|verify|aggregate [flags]")
+		return exitUsage
+	}
+	err := commands[args[0]](args[1:])
+	var usage usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitUsage
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "ppem %s: %v\n", args[0], err)
+		return exitUsage
+	case errors.Is(err, errRejected):
+		fmt.Fprintf(os.Stderr, "ppem %s: %v\n", args[0], err)
+		return exitRejected
+	default:
+		fmt.Fprintf(os.Stderr, "ppem %s: %v\n", args[0], err)
+		return exitError
+	}
+}
+
+// newFlagSet returns the flags of a command, the --circuit and --keys ones
+// being added when circuit and keysDir are not nil
+func newFlagSet(name string, circuit, keysDir *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("ppem "+name, flag.ContinueOnError)
+	if circuit != nil {
+		fs.StringVar(circuit, "circuit", "", "circuit: "+circuitNames())
+	}
+	if keysDir != nil {
+		fs.StringVar(keysDir, "keys", "keys", "directory of the constraint systems and keys")
+	}
+	return fs
+}
+
+// shapeFlags adds the flags of the shape of ProofTx and ProofF
+func shapeFlags(fs *flag.FlagSet) *shape {
+	var s shape
+	fs.IntVar(&s.l, "l", 8, "number of notes spent (tx)")
+	fs.IntVar(&s.m, "m", 8, "number of notes created (tx)")
+	fs.IntVar(&s.h, "h", 3, "depth of the merkle tree (tx)")
+	fs.IntVar(&s.buyers, "buyers", 4, "number of buyers (f)")
+	fs.IntVar(&s.sellers, "sellers", 4, "number of sellers (f)")
+	fs.StringVar(&s.rule, "rule", "uniform", "pricing rule: uniform, pay-as-bid, mcafee or k-double:<num>/<den> (f)")
+	return &s
+}
+
+func circuitNames() string {
+	names := make([]string, 0, len(circuitSpecs))
+	for name := range circuitSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// parse parses the flags and looks the circuit up
+func parse(fs *flag.FlagSet, args []string, circuit *string) (*circuitSpec, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, usageError{err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return nil, usageError{fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	}
+	if circuit == nil {
+		return nil, nil
+	}
+	spec, ok := circuitSpecs[*circuit]
+	if !ok {
+		return nil, usageError{fmt.Sprintf("--circuit must be one of %s", circuitNames())}
+	}
+	return spec, nil
+}
+
+// backendFlag adds the --backend flag
+func backendFlag(fs *flag.FlagSet) *string {
+	return fs.String("backend", backend.GROTH16.String(), "proving system, groth16 or plonk")
+}
+
+// srsFlags adds the flags of the KZG SRS of the PlonK setup
+func srsFlags(fs *flag.FlagSet) *keys.SRS {
+	var srs keys.SRS
+	fs.StringVar(&srs.Path, "srs", "", "file of the canonical KZG SRS of a ceremony (plonk)")
+	fs.StringVar(&srs.LagrangePath, "srs-lagrange", "", "file of the Lagrange form of the SRS, computed from --srs by default (plonk)")
+	fs.BoolVar(&srs.Unsafe, "unsafe-srs", false, "generate an SRS whose secret is known, for tests only (plonk)")
+	return &srs
+}
+
+// parseBackend parses the value of the --backend flag
+func parseBackend(name string) (backend.ID, error) {
+	b, err := keys.ParseBackend(name)
+	if err != nil {
+		return b, usageError{err.Error()}
+	}
+	return b, nil
+}
+
+func setup(args []string) error {
+	var circuit, keysDir string
+	fs := newFlagSet("setup", &circuit, &keysDir)
+	backendName := backendFlag(fs)
+	srs := srsFlags(fs)
+	s := shapeFlags(fs)
+	spec, err := parse(fs, args, &circuit)
+	if err != nil {
+		return err
+	}
+	b, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	if b == backend.PLONK && srs.Path == "" && !srs.Unsafe {
+		return usageError{"the plonk setup needs --srs, or --unsafe-srs for tests"}
+	}
+
+	params := spec.params(*s)
+	c, err := spec.circuit(params)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	res, err := keys.New(keys.Curve, b, keysDir, spec.name, params, c, *srs)
+	if err != nil {
+		return err
+	}
+	for _, kind := range []keys.Kind{keys.KindConstraintSystem, keys.KindProvingKey, keys.KindVerifyingKey} {
+		fmt.Println(res.Header.Path(keysDir, kind))
+	}
+	return nil
+}
+
+func randomWitness(args []string) error {
+	var circuit, out string
+	fs := newFlagSet("witness", &circuit, nil)
+	fs.StringVar(&out, "out", "", "file the secrets are written to (default stdout)")
+	s := shapeFlags(fs)
+	spec, err := parse(fs, args, &circuit)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return spec.random(w, *s)
+}
+
+func prove(args []string) error {
+	var circuit, keysDir, witnessPath, proofPath string
+	fs := newFlagSet("prove", &circuit, &keysDir)
+	backendName := backendFlag(fs)
+	fs.StringVar(&witnessPath, "witness", "", "JSON file of the secrets")
+	fs.StringVar(&proofPath, "proof", "", "file the proof bundle is written to, in JSON if it ends with .json")
+	spec, err := parse(fs, args, &circuit)
+	if err != nil {
+		return err
+	}
+	if witnessPath == "" || proofPath == "" {
+		return usageError{"--witness and --proof are required"}
+	}
+	b, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(witnessPath)
+	if err != nil {
+		return err
+	}
+	assignment, params, err := spec.assign(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", witnessPath, err)
+	}
+
+	c, err := spec.circuit(params)
+	if err != nil {
+		return err
+	}
+	setup, err := keys.Load(keys.Curve, b, keysDir, spec.name, params, c)
+	if err != nil {
+		return err
+	}
+	proof, err := bundle.Prove(setup, assignment)
+	if err != nil {
+		return err
+	}
+	return proof.WriteFile(proofPath)
+}
+
+func verify(args []string) error {
+	var keysDir, proofPath string
+	fs := newFlagSet("verify", nil, &keysDir)
+	fs.StringVar(&proofPath, "proof", "", "proof bundle, in JSON if it ends with .json")
+	if _, err := parse(fs, args, nil); err != nil {
+		return err
+	}
+	if proofPath == "" {
+		return usageError{"--proof is required"}
+	}
+
+	b, err := bundle.ReadFile(proofPath)
+	if err != nil {
+		return err
+	}
+	backendID, err := keys.ParseBackend(b.Backend)
+	if err != nil {
+		return err
+	}
+	var c frontend.Circuit
+	curve := keys.Curve
+	if b.Circuit == aggregateName {
+		// the aggregate circuit embeds the verifying key of ProofReg
+		curve = aggregate.Curve
+		c, err = aggregateCircuit(keysDir, b.Params)
+	} else {
+		var spec *circuitSpec
+		if spec, err = specByName(b.Circuit); err == nil {
+			c, err = spec.circuit(b.Params)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	_, h, err := keys.Compile(curve, backendID, b.Circuit, b.Params, c)
+	if err != nil {
+		return err
+	}
+	vk, err := keys.LoadVerifyingKey(keysDir, h)
+	if err != nil {
+		return err
+	}
+	if err := b.Check(h, vk); err != nil {
+		return err
+	}
+	if err := b.Verify(h, vk); err != nil {
+		return fmt.Errorf("%w: %v", errRejected, err)
+	}
+	fmt.Println("proof verified")
+	return nil
+}
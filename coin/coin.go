@@ -0,0 +1,106 @@
+package coin
+
+import (
+	"math/big"
+
+	bls12377_fp "github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	mimc_bw6_761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/ps_threshold"
+)
+
+type Coin struct {
+	// Value of the coin
+	V [48]byte
+	// Public key of the owner
+	Pk [48]byte
+	// Secret key of the owner
+	Sk [48]byte
+	// Coin ID
+	Rho [48]byte
+	// Randomness used to generate the commitment
+	R [48]byte
+	// Signature of the coin
+	Signature ps_threshold.Signature
+}
+
+// Take a random Rho and R and set the coin's values and the coin' owner's public key
+func (coin *Coin) CreateCoinToMint(pk [48]byte, v big.Int) *Coin {
+
+	coin.V = new(bls12377_fp.Element).SetBigInt(&v).Bytes()
+	coin.Pk = pk
+
+	var rho_fp bls12377_fp.Element
+	rho_fp.SetRandom()
+	rho_bytes := rho_fp.Bytes()
+	copy(coin.Rho[:], rho_bytes[:])
+
+	var r_fp bls12377_fp.Element
+	r_fp.SetRandom()
+	r_bytes := r_fp.Bytes()
+	copy(coin.R[:], r_bytes[:])
+
+	return coin
+}
+
+// Take a random R and set the coin's values and the coin' owner's public key.
+// Rho is derived from the serial numbers of the coins poured and the index of
+// the coin among the new ones (see DeriveRho), so that two new coins never
+// share a rho
+func (coin *Coin) CreateCoinToPour(pk [48]byte, v big.Int, sn_old []fr.Element, index int) *Coin {
+	coin.V = new(bls12377_fp.Element).SetBigInt(&v).Bytes()
+	coin.Pk = pk
+
+	coin.Rho = DeriveRho(sn_old, index)
+
+	var r_fp bls12377_fp.Element
+	r_fp.SetRandom()
+	r_bytes := r_fp.Bytes()
+	copy(coin.R[:], r_bytes[:])
+
+	return coin
+}
+
+// DeriveRho returns rho = H(sn_old_1, ..., sn_old_l, index), the rho of the
+// index-th coin created by pouring the coins of serial numbers sn_old, as
+// note.NativeDeriveRho computes it and ProofDraw and ProofTx enforce it. The
+// element is written big-endian in the last 32 bytes.
+func DeriveRho(sn_old []fr.Element, index int) [48]byte {
+	var res [48]byte
+	rho := note.NativeDeriveRho(index, sn_old...)
+	rho_bytes := rho.Bytes()
+	copy(res[48-fr.Bytes:], rho_bytes[:])
+	return res
+}
+
+func (c *Coin) CommitCoin() [48]byte {
+	if c.R == [48]byte{} {
+		// Chose a random R if not chosen already
+		var r_fp bls12377_fp.Element
+		r_fp.SetRandom()
+		r_bytes := r_fp.Bytes()
+		copy(c.R[:], r_bytes[:])
+	}
+	mimc := mimc_bw6_761.NewMiMC()
+	_, err := mimc.Write(c.V[:])
+	if err != nil {
+		panic(err)
+	}
+	_, err = mimc.Write(c.Pk[:])
+	if err != nil {
+		panic(err)
+	}
+	_, err = mimc.Write(c.Rho[:])
+	if err != nil {
+		panic(err)
+	}
+	_, err = mimc.Write(c.R[:])
+	if err != nil {
+		panic(err)
+	}
+	var res_buf []byte
+	res_buf = mimc.Sum(res_buf)
+	return [48]byte(res_buf)
+}
@@ -0,0 +1,58 @@
+package coin
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// rhoCircuit asserts that Rho is the rho of the J-th note created by spending
+// the notes of serial numbers Sn, as ProofDraw and ProofTx do
+type rhoCircuit struct {
+	Sn  []frontend.Variable
+	Rho frontend.Variable `gnark:",public"`
+	j   int
+}
+
+func (c *rhoCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(c.Rho, note.DeriveRho(api, c.j, c.Sn...))
+	return nil
+}
+
+func TestDeriveRho(t *testing.T) {
+	Sn := make([]fr.Element, 3)
+	for i := range Sn {
+		if _, err := Sn[i].SetRandom(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for j := 0; j < 2; j++ {
+		var coin Coin
+		coin.CreateCoinToPour([48]byte{}, *big.NewInt(10), Sn, j)
+		var rho fr.Element
+		if err := rho.SetBytesCanonical(coin.Rho[48-fr.Bytes:]); err != nil {
+			t.Fatal(err)
+		}
+
+		circuit := &rhoCircuit{Sn: make([]frontend.Variable, len(Sn)), j: j}
+		assignment := &rhoCircuit{Sn: make([]frontend.Variable, len(Sn)), Rho: rho}
+		for i := range Sn {
+			assignment.Sn[i] = Sn[i]
+		}
+		if err := test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()); err != nil {
+			t.Fatalf("the rho of coin %d isn't the one of the circuits: %v", j, err)
+		}
+
+		// the rho of another index is refused
+		other := &rhoCircuit{Sn: make([]frontend.Variable, len(Sn)), j: j + 1}
+		if err := test.IsSolved(other, assignment, ecc.BLS12_377.ScalarField()); err == nil {
+			t.Fatalf("the rho of coin %d is accepted for coin %d", j, j+1)
+		}
+	}
+}
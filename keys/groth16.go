@@ -0,0 +1,89 @@
+package keys
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// SaveGroth16 writes the constraint system and the groth16 keys of the
+// circuit described by h in dir
+func SaveGroth16(dir string, h Header, ccs constraint.ConstraintSystem, pk groth16.ProvingKey, vk groth16.VerifyingKey) error {
+	if err := writeFile(dir, h, KindConstraintSystem, ccs); err != nil {
+		return err
+	}
+	if err := writeFile(dir, h, KindProvingKey, rawWriter{pk}); err != nil {
+		return err
+	}
+	return writeFile(dir, h, KindVerifyingKey, vk)
+}
+
+// LoadGroth16 reads the constraint system and the groth16 keys of the circuit
+// described by expected from dir
+func LoadGroth16(dir string, expected Header) (constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey, error) {
+	curve, err := ecc.IDFromString(expected.Curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ccs := groth16.NewCS(curve)
+	if err := readFile(dir, expected, KindConstraintSystem, ccs); err != nil {
+		return nil, nil, nil, err
+	}
+	// the header could have been copied on another constraint system
+	hash, err := HashConstraintSystem(ccs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if hash != expected.CSHash {
+		return nil, nil, nil, fmt.Errorf("%s: %w: the constraint system changed", expected.Path(dir, KindConstraintSystem), ErrMismatch)
+	}
+
+	pk := groth16.NewProvingKey(curve)
+	if err := readFile(dir, expected, KindProvingKey, rawReader{pk}); err != nil {
+		return nil, nil, nil, err
+	}
+	vk, err := LoadGroth16VerifyingKey(dir, expected)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ccs, pk, vk, nil
+}
+
+// LoadGroth16VerifyingKey only reads the groth16 verifying key of the circuit
+// described by expected from dir
+func LoadGroth16VerifyingKey(dir string, expected Header) (groth16.VerifyingKey, error) {
+	curve, err := ecc.IDFromString(expected.Curve)
+	if err != nil {
+		return nil, err
+	}
+	vk := groth16.NewVerifyingKey(curve)
+	if err := readFile(dir, expected, KindVerifyingKey, vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// rawWriter writes the proving key without compressing its points, which
+// makes loading it much faster
+type rawWriter struct {
+	pk groth16.ProvingKey
+}
+
+func (w rawWriter) WriteTo(out io.Writer) (int64, error) {
+	return w.pk.WriteRawTo(out)
+}
+
+// rawReader reads the proving key without checking its points, which takes as
+// long as the setup itself. A corrupted proving key only yields proofs the
+// verifying key rejects.
+type rawReader struct {
+	pk groth16.ProvingKey
+}
+
+func (r rawReader) ReadFrom(in io.Reader) (int64, error) {
+	return r.pk.UnsafeReadFrom(in)
+}
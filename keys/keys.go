@@ -0,0 +1,272 @@
+// Package keys stores the compiled constraint system, the proving key and the
+// verifying key of a circuit on disk, so that the setup is run once and the
+// proofs of one run can be verified in another.
+//
+// Every file starts with a Header naming the circuit, its parameters, the
+// curve, the backend and the hash of the constraint system the keys were
+// generated for. Loading compares this header with the one of the circuit
+// compiled from the sources and refuses files that don't match.
+//
+// Circuits are proven with groth16, whose setup is specific to each circuit,
+// or with PlonK, whose keys all derive from one universal KZG SRS.
+package keys
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint"
+)
+
+// Version of the on-disk format
+const Version = 1
+
+var magic = [4]byte{'P', 'P', 'E', 'M'}
+
+// Kind is the content of a file
+type Kind uint8
+
+const (
+	KindConstraintSystem Kind = iota + 1
+	KindProvingKey
+	KindVerifyingKey
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindConstraintSystem:
+		return "constraint system"
+	case KindProvingKey:
+		return "proving key"
+	case KindVerifyingKey:
+		return "verifying key"
+	}
+	return fmt.Sprintf("kind %d", uint8(k))
+}
+
+// extension returns the file extension of the kind
+func (k Kind) extension() string {
+	switch k {
+	case KindConstraintSystem:
+		return ".ccs"
+	case KindProvingKey:
+		return ".pk"
+	case KindVerifyingKey:
+		return ".vk"
+	}
+	return ".bin"
+}
+
+// ErrMismatch is returned when a file was not generated for the circuit
+var ErrMismatch = errors.New("keys don't match the circuit")
+
+// Header identifies the circuit a file was generated for
+type Header struct {
+	Version uint16
+	Kind    Kind
+	// circuit identifier, e.g. "prooftx"
+	Circuit string
+	// parameters of the shape of the circuit, e.g. "l=8,m=8,h=3"
+	Params string
+	// curve and proving system
+	Curve   string
+	Backend string
+	// sha256 of the serialized constraint system
+	CSHash [32]byte
+}
+
+// NewHeader returns the header of the keys of the compiled circuit
+func NewHeader(b backend.ID, circuit, params string, ccs constraint.ConstraintSystem) (Header, error) {
+	curve, err := curveOf(ccs)
+	if err != nil {
+		return Header{}, err
+	}
+	hash, err := HashConstraintSystem(ccs)
+	if err != nil {
+		return Header{}, err
+	}
+	return Header{
+		Version: Version,
+		Circuit: circuit,
+		Params:  params,
+		Curve:   curve.String(),
+		Backend: b.String(),
+		CSHash:  hash,
+	}, nil
+}
+
+// HashConstraintSystem returns the sha256 of the serialized constraint system
+func HashConstraintSystem(ccs constraint.ConstraintSystem) ([32]byte, error) {
+	h := sha256.New()
+	if _, err := ccs.WriteTo(h); err != nil {
+		return [32]byte{}, err
+	}
+	var res [32]byte
+	copy(res[:], h.Sum(nil))
+	return res, nil
+}
+
+// HashVerifyingKey returns the sha256 of the serialized verifying key, which
+// proof bundles carry to name the key they are verified with
+func HashVerifyingKey(vk io.WriterTo) ([32]byte, error) {
+	h := sha256.New()
+	if _, err := vk.WriteTo(h); err != nil {
+		return [32]byte{}, err
+	}
+	var res [32]byte
+	copy(res[:], h.Sum(nil))
+	return res, nil
+}
+
+// curveOf returns the curve whose scalar field the constraint system is defined on
+func curveOf(ccs constraint.ConstraintSystem) (ecc.ID, error) {
+	for _, id := range ecc.Implemented() {
+		if id.ScalarField().Cmp(ccs.Field()) == 0 {
+			return id, nil
+		}
+	}
+	return ecc.UNKNOWN, fmt.Errorf("no curve has the scalar field of the constraint system")
+}
+
+// Check returns ErrMismatch if the file described by h was not generated for
+// the circuit described by expected. The kinds are not compared.
+func (h *Header) Check(expected *Header) error {
+	switch {
+	case h.Version != expected.Version:
+		return fmt.Errorf("%w: format version %d, expected %d", ErrMismatch, h.Version, expected.Version)
+	case h.Circuit != expected.Circuit:
+		return fmt.Errorf("%w: circuit %q, expected %q", ErrMismatch, h.Circuit, expected.Circuit)
+	case h.Params != expected.Params:
+		return fmt.Errorf("%w: parameters %q, expected %q", ErrMismatch, h.Params, expected.Params)
+	case h.Curve != expected.Curve:
+		return fmt.Errorf("%w: curve %s, expected %s", ErrMismatch, h.Curve, expected.Curve)
+	case h.Backend != expected.Backend:
+		return fmt.Errorf("%w: backend %s, expected %s", ErrMismatch, h.Backend, expected.Backend)
+	case h.CSHash != expected.CSHash:
+		return fmt.Errorf("%w: the constraint system changed", ErrMismatch)
+	}
+	return nil
+}
+
+// Path returns the path of the file of the given kind in dir
+func (h *Header) Path(dir string, kind Kind) string {
+	name := h.Circuit
+	if h.Params != "" {
+		name += "_" + strings.NewReplacer("=", "", ",", "_", ":", "-", "/", "-").Replace(h.Params)
+	}
+	return filepath.Join(dir, name+"."+h.Backend+kind.extension())
+}
+
+// WriteTo encodes the header: magic, version, kind, the length-prefixed
+// strings and the hash
+func (h *Header) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.Write(magic[:])
+	binary.Write(&buf, binary.BigEndian, h.Version)
+	buf.WriteByte(byte(h.Kind))
+	for _, s := range []string{h.Circuit, h.Params, h.Curve, h.Backend} {
+		binary.Write(&buf, binary.BigEndian, uint16(len(s)))
+		buf.WriteString(s)
+	}
+	buf.Write(h.CSHash[:])
+	return buf.WriteTo(w)
+}
+
+// ReadFrom decodes a header written by WriteTo
+func (h *Header) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	read := func(v any) error {
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			return err
+		}
+		n += int64(binary.Size(v))
+		return nil
+	}
+
+	var m [4]byte
+	if err := read(&m); err != nil {
+		return n, err
+	}
+	if m != magic {
+		return n, fmt.Errorf("not a key file")
+	}
+	if err := read(&h.Version); err != nil {
+		return n, err
+	}
+	if h.Version != Version {
+		return n, fmt.Errorf("%w: format version %d, expected %d", ErrMismatch, h.Version, Version)
+	}
+	if err := read(&h.Kind); err != nil {
+		return n, err
+	}
+	for _, s := range []*string{&h.Circuit, &h.Params, &h.Curve, &h.Backend} {
+		var length uint16
+		if err := read(&length); err != nil {
+			return n, err
+		}
+		b := make([]byte, length)
+		if err := read(b); err != nil {
+			return n, err
+		}
+		*s = string(b)
+	}
+	err := read(&h.CSHash)
+	return n, err
+}
+
+// writeFile writes the header followed by the payload to the file of the
+// given kind
+func writeFile(dir string, h Header, kind Kind, payload io.WriterTo) error {
+	h.Kind = kind
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(h.Path(dir, kind))
+	if err != nil {
+		return err
+	}
+	if _, err := h.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := payload.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readFile reads the file of the given kind into payload, after checking its
+// header against expected
+func readFile(dir string, expected Header, kind Kind, payload io.ReaderFrom) error {
+	path := expected.Path(dir, kind)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var h Header
+	if _, err := h.ReadFrom(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if h.Kind != kind {
+		return fmt.Errorf("%s: %w: contains a %s, expected a %s", path, ErrMismatch, h.Kind, kind)
+	}
+	if err := h.Check(&expected); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := payload.ReadFrom(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package keys
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/kzg"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/kzg"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/test/unsafekzg"
+)
+
+// SRS locates the KZG SRS the PlonK keys derive from. A deployment loads the
+// canonical SRS of a ceremony, written by kzg.SRS.WriteTo; an SRS generated
+// locally knows its secret, which forges proofs, and must be asked for.
+type SRS struct {
+	// file of the canonical SRS
+	Path string
+	// file of its Lagrange form for the size of the circuit, computed from the
+	// canonical SRS when empty
+	LagrangePath string
+	// Unsafe generates the SRS locally from a known secret instead, and caches
+	// it in ~/.gnark/kzg so that it is shared by every circuit of the same
+	// size: it is only meant for tests
+	Unsafe bool
+}
+
+// SetupPlonK runs the PlonK setup of the constraint system, compiled on the
+// scalar field of curve, with the SRS
+func SetupPlonK(curve ecc.ID, ccs constraint.ConstraintSystem, srs SRS) (plonk.ProvingKey, plonk.VerifyingKey, error) {
+	canonical, lagrange, err := srs.load(curve, ccs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plonk.Setup(ccs, canonical, lagrange)
+}
+
+// load returns the canonical SRS on curve and its Lagrange form for the size
+// of the constraint system
+func (srs SRS) load(curve ecc.ID, ccs constraint.ConstraintSystem) (kzg.SRS, kzg.SRS, error) {
+	if srs.Unsafe {
+		if srs.Path != "" || srs.LagrangePath != "" {
+			return nil, nil, fmt.Errorf("an unsafe SRS can't be loaded from a file")
+		}
+		return unsafekzg.NewSRS(ccs, unsafekzg.WithFSCache())
+	}
+	if srs.Path == "" {
+		return nil, nil, fmt.Errorf("the PlonK setup needs the SRS of a ceremony, or an unsafe SRS for tests")
+	}
+	canonical, err := readSRS(curve, srs.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the Lagrange form has the size of the evaluation domain of the circuit
+	size := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables()))
+	if srs.LagrangePath != "" {
+		lagrange, err := readSRS(curve, srs.LagrangePath)
+		return canonical, lagrange, err
+	}
+	var lagrange kzg.SRS
+	switch c := canonical.(type) {
+	case *kzg_bls12377.SRS:
+		if uint64(len(c.Pk.G1)) < size {
+			return nil, nil, fmt.Errorf("%s: the SRS has %d points, the circuit needs %d", srs.Path, len(c.Pk.G1), size)
+		}
+		l := &kzg_bls12377.SRS{Vk: c.Vk}
+		l.Pk.G1, err = kzg_bls12377.ToLagrangeG1(c.Pk.G1[:size])
+		lagrange = l
+	case *kzg_bw6761.SRS:
+		if uint64(len(c.Pk.G1)) < size {
+			return nil, nil, fmt.Errorf("%s: the SRS has %d points, the circuit needs %d", srs.Path, len(c.Pk.G1), size)
+		}
+		l := &kzg_bw6761.SRS{Vk: c.Vk}
+		l.Pk.G1, err = kzg_bw6761.ToLagrangeG1(c.Pk.G1[:size])
+		lagrange = l
+	default:
+		return nil, nil, fmt.Errorf("no Lagrange form of the SRS on %s, it must be given as a file", curve)
+	}
+	return canonical, lagrange, err
+}
+
+// readSRS reads a KZG SRS on curve from path
+func readSRS(curve ecc.ID, path string) (kzg.SRS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	srs := kzg.NewSRS(curve)
+	if _, err := srs.ReadFrom(bufio.NewReader(f)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return srs, nil
+}
+
+// SavePlonK writes the constraint system and the PlonK keys of the circuit
+// described by h in dir
+func SavePlonK(dir string, h Header, ccs constraint.ConstraintSystem, pk plonk.ProvingKey, vk plonk.VerifyingKey) error {
+	if err := writeFile(dir, h, KindConstraintSystem, ccs); err != nil {
+		return err
+	}
+	if err := writeFile(dir, h, KindProvingKey, plonkRawWriter{pk}); err != nil {
+		return err
+	}
+	return writeFile(dir, h, KindVerifyingKey, vk)
+}
+
+// LoadPlonK reads the constraint system and the PlonK keys of the circuit
+// described by expected from dir
+func LoadPlonK(dir string, expected Header) (constraint.ConstraintSystem, plonk.ProvingKey, plonk.VerifyingKey, error) {
+	curve, err := ecc.IDFromString(expected.Curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ccs := plonk.NewCS(curve)
+	if err := readFile(dir, expected, KindConstraintSystem, ccs); err != nil {
+		return nil, nil, nil, err
+	}
+	// the header could have been copied on another constraint system
+	hash, err := HashConstraintSystem(ccs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if hash != expected.CSHash {
+		return nil, nil, nil, fmt.Errorf("%s: %w: the constraint system changed", expected.Path(dir, KindConstraintSystem), ErrMismatch)
+	}
+
+	pk := plonk.NewProvingKey(curve)
+	if err := readFile(dir, expected, KindProvingKey, plonkRawReader{pk}); err != nil {
+		return nil, nil, nil, err
+	}
+	vk, err := LoadPlonKVerifyingKey(dir, expected)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ccs, pk, vk, nil
+}
+
+// LoadPlonKVerifyingKey only reads the PlonK verifying key of the circuit
+// described by expected from dir
+func LoadPlonKVerifyingKey(dir string, expected Header) (plonk.VerifyingKey, error) {
+	curve, err := ecc.IDFromString(expected.Curve)
+	if err != nil {
+		return nil, err
+	}
+	vk := plonk.NewVerifyingKey(curve)
+	if err := readFile(dir, expected, KindVerifyingKey, vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// plonkRawWriter writes the proving key without compressing its points
+type plonkRawWriter struct {
+	pk plonk.ProvingKey
+}
+
+func (w plonkRawWriter) WriteTo(out io.Writer) (int64, error) {
+	return w.pk.WriteRawTo(out)
+}
+
+// plonkRawReader reads the proving key without checking its points, see
+// rawReader
+type plonkRawReader struct {
+	pk plonk.ProvingKey
+}
+
+func (r plonkRawReader) ReadFrom(in io.Reader) (int64, error) {
+	return r.pk.UnsafeReadFrom(in)
+}
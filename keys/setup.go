@@ -0,0 +1,166 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+)
+
+// Curve is the curve the circuits of the mechanism are proven on. Its scalar
+// field is the base field of the twisted Edwards curve the bids are encrypted
+// with, and its proofs can be verified in a circuit proven on BW6-761 (see
+// package aggregate).
+var Curve = ecc.BLS12_377
+
+// Setup is a circuit compiled with the keys of its backend
+type Setup struct {
+	Header Header
+	CCS    constraint.ConstraintSystem
+	// groth16 or plonk keys, depending on Header.Backend
+	PK io.WriterTo
+	VK io.WriterTo
+}
+
+// ParseBackend returns the backend named s, groth16 or plonk
+func ParseBackend(s string) (backend.ID, error) {
+	switch s {
+	case backend.GROTH16.String():
+		return backend.GROTH16, nil
+	case backend.PLONK.String():
+		return backend.PLONK, nil
+	}
+	return backend.UNKNOWN, fmt.Errorf("unknown backend %q, expected %s or %s", s, backend.GROTH16, backend.PLONK)
+}
+
+// Compile compiles the circuit on the scalar field of curve, into a R1CS for
+// groth16 and a SparseR1CS for PlonK, and returns the header of its keys
+func Compile(curve ecc.ID, b backend.ID, name, params string, circuit frontend.Circuit) (constraint.ConstraintSystem, Header, error) {
+	var builder frontend.NewBuilder
+	switch b {
+	case backend.GROTH16:
+		builder = r1cs.NewBuilder
+	case backend.PLONK:
+		builder = scs.NewBuilder
+	default:
+		return nil, Header{}, fmt.Errorf("unsupported backend %s", b)
+	}
+	ccs, err := frontend.Compile(curve.ScalarField(), builder, circuit)
+	if err != nil {
+		return nil, Header{}, err
+	}
+	h, err := NewHeader(b, name, params, ccs)
+	if err != nil {
+		return nil, Header{}, err
+	}
+	return ccs, h, nil
+}
+
+// New compiles the circuit and loads its keys from dir. When none of its files
+// exist yet, it runs the setup of the backend, PlonK deriving the keys from
+// srs, and saves them; when only some of them exist, it refuses to overwrite
+// the others. Keys generated for another version of the circuit are refused.
+func New(curve ecc.ID, b backend.ID, dir, name, params string, circuit frontend.Circuit, srs SRS) (*Setup, error) {
+	ccs, h, err := Compile(curve, b, name, params, circuit)
+	if err != nil {
+		return nil, err
+	}
+	s := &Setup{Header: h, CCS: ccs}
+
+	var missing []string
+	kinds := []Kind{KindConstraintSystem, KindProvingKey, KindVerifyingKey}
+	for _, kind := range kinds {
+		if _, err := os.Stat(h.Path(dir, kind)); errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, h.Path(dir, kind))
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	switch len(missing) {
+	case 0:
+		if err := s.load(dir); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case len(kinds):
+	default:
+		return nil, fmt.Errorf("%s: %w, remove the other files of the circuit to run the setup again", strings.Join(missing, ", "), fs.ErrNotExist)
+	}
+
+	switch b {
+	case backend.GROTH16:
+		pk, vk, err := groth16.Setup(ccs)
+		if err != nil {
+			return nil, err
+		}
+		s.PK, s.VK = pk, vk
+		if err := SaveGroth16(dir, h, ccs, pk, vk); err != nil {
+			return nil, err
+		}
+	case backend.PLONK:
+		pk, vk, err := SetupPlonK(curve, ccs, srs)
+		if err != nil {
+			return nil, err
+		}
+		s.PK, s.VK = pk, vk
+		if err := SavePlonK(dir, h, ccs, pk, vk); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Load compiles the circuit and loads the keys generated by New from dir
+func Load(curve ecc.ID, b backend.ID, dir, name, params string, circuit frontend.Circuit) (*Setup, error) {
+	ccs, h, err := Compile(curve, b, name, params, circuit)
+	if err != nil {
+		return nil, err
+	}
+	s := &Setup{Header: h, CCS: ccs}
+	if err := s.load(dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the keys of the compiled circuit from dir
+func (s *Setup) load(dir string) error {
+	switch s.Header.Backend {
+	case backend.GROTH16.String():
+		_, pk, vk, err := LoadGroth16(dir, s.Header)
+		if err != nil {
+			return err
+		}
+		s.PK, s.VK = pk, vk
+	case backend.PLONK.String():
+		_, pk, vk, err := LoadPlonK(dir, s.Header)
+		if err != nil {
+			return err
+		}
+		s.PK, s.VK = pk, vk
+	}
+	return nil
+}
+
+// LoadVerifyingKey only reads the verifying key of the circuit described by
+// expected from dir
+func LoadVerifyingKey(dir string, expected Header) (io.WriterTo, error) {
+	b, err := ParseBackend(expected.Backend)
+	if err != nil {
+		return nil, err
+	}
+	if b == backend.PLONK {
+		return LoadPlonKVerifyingKey(dir, expected)
+	}
+	return LoadGroth16VerifyingKey(dir, expected)
+}
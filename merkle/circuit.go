@@ -0,0 +1,23 @@
+package merkle
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// VerifyPath asserts that leaf is in the tree of root Rt. Path[k] is 1 when the
+// node at level k is a right child and Siblings[k] is its sibling, as given by
+// Witness.Path and Witness.Siblings.
+func VerifyPath(api frontend.API, Rt, leaf frontend.Variable, Path, Siblings []frontend.Variable) {
+	node := leaf
+	for k := range Siblings {
+		api.AssertIsBoolean(Path[k])
+		left := api.Select(Path[k], Siblings[k], node)
+		right := api.Select(Path[k], node, Siblings[k])
+		node_mimc, _ := mimc.NewMiMC(api)
+		node_mimc.Write(left)
+		node_mimc.Write(right)
+		node = node_mimc.Sum()
+	}
+	api.AssertIsEqual(Rt, node)
+}
@@ -0,0 +1,207 @@
+// Package merkle implements the append-only MiMC merkle tree holding the note
+// commitments. Each node is H(left, right) and empty leaves are 0.
+//
+// The tree only keeps its frontier, so that it can grow without storing the
+// leaves: the authentication path of a leaf is a Witness, taken when the leaf
+// is appended and updated on each following append. VerifyPath checks such a
+// path in-circuit.
+package merkle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+type Tree struct {
+	// depth of the tree, it holds 2^depth leaves
+	depth int
+	// number of leaves appended
+	next uint64
+	// last appended leaf
+	last fr.Element
+	// branch[k] is the last left node completed at level k
+	branch []fr.Element
+	// zeros[k] is the root of an empty subtree of height k
+	zeros []fr.Element
+}
+
+// Witness is the authentication path of a leaf
+type Witness struct {
+	// position of the leaf
+	Index uint64
+	// commitment stored in the leaf
+	Leaf fr.Element
+	// Siblings[k] is the sibling of the path at level k, from the leaf up
+	Siblings []fr.Element
+}
+
+// NewTree returns an empty tree of the given depth
+func NewTree(depth int) (*Tree, error) {
+	if depth < 1 || depth > 63 {
+		return nil, fmt.Errorf("invalid merkle tree depth %d", depth)
+	}
+	t := &Tree{
+		depth:  depth,
+		branch: make([]fr.Element, depth),
+		zeros:  make([]fr.Element, depth+1),
+	}
+	for k := 1; k <= depth; k++ {
+		t.zeros[k] = note.Hash(t.zeros[k-1], t.zeros[k-1])
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Size returns the number of leaves appended
+func (t *Tree) Size() uint64 {
+	return t.next
+}
+
+// Append adds a leaf and returns its position
+func (t *Tree) Append(leaf fr.Element) (uint64, error) {
+	if t.next == 1<<t.depth {
+		return 0, fmt.Errorf("merkle tree is full")
+	}
+	index := t.next
+	node := leaf
+	for k := 0; k < t.depth; k++ {
+		if (index>>k)&1 == 0 {
+			t.branch[k] = node
+			break
+		}
+		node = note.Hash(t.branch[k], node)
+	}
+	t.last = leaf
+	t.next++
+	return index, nil
+}
+
+// Root returns the root of the tree
+func (t *Tree) Root() fr.Element {
+	return t.subtreeRoot(t.depth)
+}
+
+// subtreeRoot returns the root of the subtree of height k holding the last
+// appended leaf, or an empty subtree if there is none
+func (t *Tree) subtreeRoot(k int) fr.Element {
+	if t.next == 0 {
+		return t.zeros[k]
+	}
+	// start from the last leaf and fold the completed nodes on its left and
+	// the empty subtrees on its right
+	index := t.next - 1
+	node := t.last
+	for j := 0; j < k; j++ {
+		if (index>>j)&1 == 1 {
+			node = note.Hash(t.branch[j], node)
+		} else {
+			node = note.Hash(node, t.zeros[j])
+		}
+	}
+	return node
+}
+
+// Witness returns the authentication path of the last appended leaf, which
+// must be leaf
+func (t *Tree) Witness(leaf fr.Element) (Witness, error) {
+	if t.next == 0 {
+		return Witness{}, fmt.Errorf("merkle tree is empty")
+	}
+	if !leaf.Equal(&t.last) {
+		return Witness{}, fmt.Errorf("the last leaf appended is %s, not %s", t.last.String(), leaf.String())
+	}
+	w := Witness{
+		Index:    t.next - 1,
+		Leaf:     leaf,
+		Siblings: make([]fr.Element, t.depth),
+	}
+	for k := 0; k < t.depth; k++ {
+		if (w.Index>>k)&1 == 1 {
+			// the left sibling is complete
+			w.Siblings[k] = t.branch[k]
+		} else {
+			// nothing was appended on the right yet
+			w.Siblings[k] = t.zeros[k]
+		}
+	}
+	return w, nil
+}
+
+// Update brings the witness up to date after a leaf was appended to t. It must
+// be called after each append following the one of the witnessed leaf.
+func (w *Witness) Update(t *Tree) error {
+	if len(w.Siblings) != t.depth {
+		return fmt.Errorf("witness depth %d does not match tree depth %d", len(w.Siblings), t.depth)
+	}
+	if t.next == 0 || t.next-1 <= w.Index {
+		return nil
+	}
+	// the new leaf lies in the sibling subtree at the level of the highest
+	// bit on which both positions differ
+	k := bits.Len64((t.next-1)^w.Index) - 1
+	w.Siblings[k] = t.subtreeRoot(k)
+	return nil
+}
+
+// Path returns the direction bits of the witness, 1 meaning that the node at
+// this level is a right child
+func (w *Witness) Path() []uint64 {
+	path := make([]uint64, len(w.Siblings))
+	for k := range path {
+		path[k] = (w.Index >> k) & 1
+	}
+	return path
+}
+
+// Root recomputes the root from the leaf and its authentication path
+func (w *Witness) Root() fr.Element {
+	node := w.Leaf
+	for k := range w.Siblings {
+		if (w.Index>>k)&1 == 1 {
+			node = note.Hash(w.Siblings[k], node)
+		} else {
+			node = note.Hash(node, w.Siblings[k])
+		}
+	}
+	return node
+}
+
+// frontier is the encoding of a tree
+type frontier struct {
+	Depth  int
+	Size   uint64
+	Last   fr.Element
+	Branch []fr.Element
+}
+
+// MarshalJSON encodes the frontier of the tree
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&frontier{Depth: t.depth, Size: t.next, Last: t.last, Branch: t.branch})
+}
+
+// UnmarshalJSON decodes a tree encoded by MarshalJSON
+func (t *Tree) UnmarshalJSON(data []byte) error {
+	var f frontier
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	res, err := NewTree(f.Depth)
+	if err != nil {
+		return err
+	}
+	if len(f.Branch) != f.Depth || f.Size > 1<<f.Depth {
+		return fmt.Errorf("invalid merkle tree of depth %d and size %d", f.Depth, f.Size)
+	}
+	res.next, res.last, res.branch = f.Size, f.Last, f.Branch
+	*t = *res
+	return nil
+}
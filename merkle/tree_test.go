@@ -0,0 +1,130 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// root computes the root of the tree of the leaves by hashing every node
+func root(depth int, leaves []fr.Element) fr.Element {
+	level := make([]fr.Element, 1<<depth)
+	copy(level, leaves)
+	for ; len(level) > 1; level = level[:len(level)/2] {
+		for i := 0; i < len(level)/2; i++ {
+			level[i] = note.Hash(level[2*i], level[2*i+1])
+		}
+	}
+	return level[0]
+}
+
+// randomTree appends n random leaves to a tree of the given depth, and returns
+// the witness of each leaf, up to date with the tree
+func randomTree(t *testing.T, depth, n int) (*Tree, []fr.Element, []Witness) {
+	t.Helper()
+	tree, err := NewTree(depth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := make([]fr.Element, n)
+	witnesses := make([]Witness, n)
+	for i := range leaves {
+		if _, err := leaves[i].SetRandom(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tree.Append(leaves[i]); err != nil {
+			t.Fatal(err)
+		}
+		for k := 0; k < i; k++ {
+			if err := witnesses[k].Update(tree); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if witnesses[i], err = tree.Witness(leaves[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tree, leaves, witnesses
+}
+
+func TestTree(t *testing.T) {
+	tree, leaves, witnesses := randomTree(t, 3, 6)
+	expected := root(3, leaves)
+	if r := tree.Root(); !r.Equal(&expected) {
+		t.Fatal("the root of the frontier isn't the root of the leaves")
+	}
+	for i := range witnesses {
+		if r := witnesses[i].Root(); !r.Equal(&expected) {
+			t.Fatalf("the witness of leaf %d doesn't authenticate it", i)
+		}
+	}
+
+	// only the last leaf appended can be witnessed
+	if _, err := tree.Witness(leaves[0]); err == nil {
+		t.Fatal("the witness of a leaf other than the last one is returned")
+	}
+	for range 2 {
+		if _, err := tree.Append(fr.Element{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tree.Append(fr.Element{}); err == nil {
+		t.Fatal("a leaf is appended to a full tree")
+	}
+}
+
+type pathCircuit struct {
+	Rt       frontend.Variable `gnark:",public"`
+	Leaf     frontend.Variable
+	Path     []frontend.Variable
+	Siblings []frontend.Variable
+}
+
+func (c *pathCircuit) Define(api frontend.API) error {
+	VerifyPath(api, c.Rt, c.Leaf, c.Path, c.Siblings)
+	return nil
+}
+
+func newPathCircuit(depth int) *pathCircuit {
+	return &pathCircuit{Path: make([]frontend.Variable, depth), Siblings: make([]frontend.Variable, depth)}
+}
+
+func assignPath(w *Witness, Rt fr.Element) *pathCircuit {
+	c := newPathCircuit(len(w.Siblings))
+	c.Rt, c.Leaf = Rt, w.Leaf
+	for k, bit := range w.Path() {
+		c.Path[k], c.Siblings[k] = bit, w.Siblings[k]
+	}
+	return c
+}
+
+func TestVerifyPath(t *testing.T) {
+	tree, _, witnesses := randomTree(t, 3, 5)
+	Rt := tree.Root()
+	field := ecc.BLS12_377.ScalarField()
+	for i := range witnesses {
+		if err := test.IsSolved(newPathCircuit(3), assignPath(&witnesses[i], Rt), field); err != nil {
+			t.Fatalf("leaf %d: %v", i, err)
+		}
+	}
+
+	w := witnesses[2]
+	for name, tamper := range map[string]func(c *pathCircuit){
+		"another leaf":    func(c *pathCircuit) { c.Leaf = witnesses[3].Leaf },
+		"another sibling": func(c *pathCircuit) { c.Siblings[1] = witnesses[0].Leaf },
+		"another index":   func(c *pathCircuit) { c.Path[0] = 1 - w.Path()[0] },
+		"no direction":    func(c *pathCircuit) { c.Path[2] = 2 },
+		"another root":    func(c *pathCircuit) { c.Rt = w.Leaf },
+	} {
+		c := assignPath(&w, Rt)
+		tamper(c)
+		if err := test.IsSolved(newPathCircuit(3), c, field); err == nil {
+			t.Errorf("%s: the path is accepted", name)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package note
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+)
+
+// plaintext is the number of elements of an encrypted note: T[0], T[1], Rho,
+// R and the base G_d of the address
+const plaintext = 6
+
+// EncryptedNote is a note created for a recipient, published next to its
+// commitment so that the recipient learns the opening of the note.
+//
+// The sender draws esk and publishes Epk = [esk]G_d. Both ends compute the
+// shared point [esk]Pk_d = [Ivk]Epk and the key K = H(shared, Epk); the
+// plaintext is masked with the MiMC keystream H(K, 0), H(K, 1), ..., and the
+// tag H(K, Cm, C) authenticates the ciphertext and binds it to the commitment.
+type EncryptedNote struct {
+	// commitment of the note
+	Cm fr.Element
+	// ephemeral key [esk]G_d
+	Epk edwards.PointAffine
+	// masked T[0], T[1], Rho, R, G_d.X and G_d.Y
+	C [plaintext]fr.Element
+	// MAC of the ciphertext
+	Tag fr.Element
+}
+
+// Encrypt encrypts the opening of the note to the address of its owner
+func (n *NativeNote) Encrypt() (EncryptedNote, error) {
+	var e EncryptedNote
+	a := n.Address()
+	if !InSubgroup(&a.G_d) || !InSubgroup(&a.Pk_d) {
+		return e, fmt.Errorf("the note has no address to encrypt to")
+	}
+	var esk fr.Element
+	if _, err := esk.SetRandom(); err != nil {
+		return e, err
+	}
+	s := esk.BigInt(new(big.Int))
+	curve := edwards.GetEdwardsCurve()
+	s.Mod(s, &curve.Order)
+
+	var shared edwards.PointAffine
+	e.Cm = n.Cm
+	e.Epk.ScalarMultiplication(&a.G_d, s)
+	shared.ScalarMultiplication(&a.Pk_d, s)
+	key := kdf(&shared, &e.Epk)
+
+	p := [plaintext]fr.Element{n.T[0], n.T[1], n.Rho, n.R, a.G_d.X, a.G_d.Y}
+	for i := range p {
+		mask := keystream(key, i)
+		e.C[i].Add(&p[i], &mask)
+	}
+	e.Tag = e.mac(key)
+	return e, nil
+}
+
+// TrialDecrypt decrypts the note with the incoming viewing key Ivk, and
+// returns false when the note wasn't sent to an address of Ivk. The spending
+// key of the note is left empty.
+func TrialDecrypt(Ivk fr.Element, e *EncryptedNote) (NativeNote, bool) {
+	// a point out of the prime order subgroup would leak bits of Ivk
+	if !InSubgroup(&e.Epk) {
+		return NativeNote{}, false
+	}
+	var shared edwards.PointAffine
+	shared.ScalarMultiplication(&e.Epk, Ivk.BigInt(new(big.Int)))
+	key := kdf(&shared, &e.Epk)
+	if tag := e.mac(key); !tag.Equal(&e.Tag) {
+		return NativeNote{}, false
+	}
+
+	var p [plaintext]fr.Element
+	for i := range p {
+		mask := keystream(key, i)
+		p[i].Sub(&e.C[i], &mask)
+	}
+	var a NativeAddress
+	a.G_d.X, a.G_d.Y = p[4], p[5]
+	if !InSubgroup(&a.G_d) {
+		return NativeNote{}, false
+	}
+	a.Pk_d.ScalarMultiplication(&a.G_d, Ivk.BigInt(new(big.Int)))
+	n := NewNativeNoteTo([2]fr.Element{p[0], p[1]}, a, p[2], p[3])
+	if !n.Cm.Equal(&e.Cm) {
+		return NativeNote{}, false
+	}
+	return n, true
+}
+
+// Scan trial decrypts the notes with the incoming viewing key Ivk, and returns
+// those sent to an address of Ivk
+func Scan(Ivk fr.Element, notes []EncryptedNote) []NativeNote {
+	var res []NativeNote
+	for i := range notes {
+		if n, ok := TrialDecrypt(Ivk, &notes[i]); ok {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// kdf returns the key H(shared, Epk)
+func kdf(shared, Epk *edwards.PointAffine) fr.Element {
+	return Hash(shared.X, shared.Y, Epk.X, Epk.Y)
+}
+
+// keystream returns the i-th mask H(K, i)
+func keystream(key fr.Element, i int) fr.Element {
+	var index fr.Element
+	index.SetUint64(uint64(i))
+	return Hash(key, index)
+}
+
+// mac returns the tag H(K, Cm, C) of the ciphertext
+func (e *EncryptedNote) mac(key fr.Element) fr.Element {
+	return Hash(append([]fr.Element{key, e.Cm}, e.C[:]...)...)
+}
+
+// InSubgroup returns true when p is a point of the prime order subgroup of the
+// twisted Edwards curve, other than the identity
+func InSubgroup(p *edwards.PointAffine) bool {
+	curve := edwards.GetEdwardsCurve()
+	var q edwards.PointAffine
+	q.ScalarMultiplication(p, &curve.Order)
+	return p.IsOnCurve() && !p.IsZero() && q.IsZero()
+}
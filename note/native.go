@@ -0,0 +1,241 @@
+package note
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	mimc_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/mimc"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+)
+
+// NativeNote is the out-of-circuit version of NoteFull. All the values live
+// in the scalar field of BLS12-377, which is the field the circuits are compiled on.
+type NativeNote struct {
+	// asset T[0] and value T[1]
+	T [2]fr.Element
+	// Public key of the owner
+	Pk fr.Element
+	// Spending key of the owner, zero for a note sent to someone else
+	Sk fr.Element
+	// index d and address (G_d, Pk_d) of the owner
+	D    uint64
+	G_d  edwards.PointAffine
+	Pk_d edwards.PointAffine
+	// Coin ID
+	Rho fr.Element
+	// Randomness used to generate the commitment
+	R fr.Element
+	// commitment of the coin
+	Cm fr.Element
+}
+
+// Hash returns the MiMC digest of the elements, as mimc.MiMC does in-circuit
+func Hash(elems ...fr.Element) fr.Element {
+	h := mimc_bls12377.NewMiMC()
+	for i := range elems {
+		b := elems[i].Bytes()
+		if _, err := h.Write(b[:]); err != nil {
+			// canonical encodings are always accepted
+			panic(err)
+		}
+	}
+	var res fr.Element
+	res.SetBytes(h.Sum(nil))
+	return res
+}
+
+// AssetID returns the identifier of the asset of the given name, e.g.
+// "EUR-cent" or "kWh-slot-42", to be stored in T[0]. The identifier is the
+// big-endian encoding of the length of the name followed by the name, so that
+// distinct names never share one, even when one is the other with leading zero
+// bytes.
+func AssetID(name string) (fr.Element, error) {
+	var id fr.Element
+	if len(name) == 0 || len(name) > fr.Bytes-2 {
+		return id, fmt.Errorf("an asset name has between 1 and %d bytes, got %d", fr.Bytes-2, len(name))
+	}
+	id.SetBytes(append([]byte{byte(len(name))}, name...))
+	return id, nil
+}
+
+// Variable converts a native element into a value usable in a circuit assignment
+func Variable(e fr.Element) frontend.Variable {
+	return e.BigInt(new(big.Int))
+}
+
+// NativeKeys are the keys derived from a spending key
+type NativeKeys struct {
+	// spending key
+	Sk fr.Element
+	// nullifier key H(Sk, 1)
+	Nk fr.Element
+	// incoming viewing key H(Sk, 2)
+	Ivk fr.Element
+	// diversifier key H(Sk, 3)
+	Dk fr.Element
+}
+
+// NativeAddress is a payment address, the notes sent to it committing to
+// Pk = H(G_d, Pk_d)
+type NativeAddress struct {
+	G_d  edwards.PointAffine
+	Pk_d edwards.PointAffine
+}
+
+// NewNativeKeys derives the keys of the spending key Sk
+func NewNativeKeys(Sk fr.Element) NativeKeys {
+	var one, two, three fr.Element
+	one.SetUint64(1)
+	two.SetUint64(2)
+	three.SetUint64(3)
+	return NativeKeys{
+		Sk:  Sk,
+		Nk:  Hash(Sk, one),
+		Ivk: Hash(Sk, two),
+		Dk:  Hash(Sk, three),
+	}
+}
+
+// Address returns the d-th payment address of the keys, whose base is
+// G_d = [H(Dk, d)]G, G being the base point of the twisted Edwards curve of
+// BLS12-377
+func (k *NativeKeys) Address(d uint64) NativeAddress {
+	var index fr.Element
+	index.SetUint64(d)
+	s := Hash(k.Dk, index)
+	var a NativeAddress
+	base := edwards.GetEdwardsCurve().Base
+	a.G_d.ScalarMultiplication(&base, s.BigInt(new(big.Int)))
+	a.Pk_d.ScalarMultiplication(&a.G_d, k.Ivk.BigInt(new(big.Int)))
+	return a
+}
+
+// Pk returns the public key H(G_d, Pk_d) of the address
+func (a *NativeAddress) Pk() fr.Element {
+	return Hash(a.G_d.X, a.G_d.Y, a.Pk_d.X, a.Pk_d.Y)
+}
+
+// Owns returns true when the address is an address of the keys, whatever its
+// index
+func (k *NativeKeys) Owns(a *NativeAddress) bool {
+	var Pk_d edwards.PointAffine
+	Pk_d.ScalarMultiplication(&a.G_d, k.Ivk.BigInt(new(big.Int)))
+	return Pk_d.Equal(&a.Pk_d)
+}
+
+// NativeKeyGen returns Pk = H(G_d, [Ivk]G_d), the public key of the d-th
+// address of the spending key Sk
+func NativeKeyGen(Sk fr.Element, d uint64) fr.Element {
+	k := NewNativeKeys(Sk)
+	a := k.Address(d)
+	return a.Pk()
+}
+
+// NativeCommitment returns Cm = H(T[0], T[1], R, Rho, Pk)
+func NativeCommitment(T [2]fr.Element, R, Rho, Pk fr.Element) fr.Element {
+	return Hash(T[0], T[1], R, Rho, Pk)
+}
+
+// NativeSerialNumber returns Sn = H(Nk, Rho)
+func NativeSerialNumber(Nk, Rho fr.Element) fr.Element {
+	return Hash(Nk, Rho)
+}
+
+// NativeDeriveRho returns Rho_j = H(Sn_1, ..., Sn_l, j)
+func NativeDeriveRho(j int, Sn ...fr.Element) fr.Element {
+	var index fr.Element
+	index.SetUint64(uint64(j))
+	return Hash(append(append([]fr.Element{}, Sn...), index)...)
+}
+
+// NewNativeNote derives Pk and Cm from the secrets of the note, owned by the
+// first address of Sk
+func NewNativeNote(T [2]fr.Element, Sk, Rho, R fr.Element) NativeNote {
+	return NewNativeNoteAt(T, Sk, 0, Rho, R)
+}
+
+// NewNativeNoteAt derives Pk and Cm from the secrets of the note, owned by the
+// d-th address of Sk
+func NewNativeNoteAt(T [2]fr.Element, Sk fr.Element, d uint64, Rho, R fr.Element) NativeNote {
+	k := NewNativeKeys(Sk)
+	n := NewNativeNoteTo(T, k.Address(d), Rho, R)
+	n.Sk, n.D = Sk, d
+	return n
+}
+
+// NewNativeNoteTo returns the note sent to the address, whose spending key is
+// unknown
+func NewNativeNoteTo(T [2]fr.Element, a NativeAddress, Rho, R fr.Element) NativeNote {
+	n := NativeNote{T: T, Rho: Rho, R: R, G_d: a.G_d, Pk_d: a.Pk_d}
+	n.Pk = a.Pk()
+	n.Cm = NativeCommitment(T, R, Rho, n.Pk)
+	return n
+}
+
+// Address returns the address of the owner of the note
+func (n *NativeNote) Address() NativeAddress {
+	return NativeAddress{G_d: n.G_d, Pk_d: n.Pk_d}
+}
+
+// RandomNativeNote is the native version of GenerateNotes
+func RandomNativeNote() (NativeNote, error) {
+	max := big.NewInt((1 << 63) - 1)
+	var v [5]fr.Element
+	for i := range v {
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return NativeNote{}, err
+		}
+		v[i].SetBigInt(r)
+	}
+	return NewNativeNote([2]fr.Element{v[0], v[1]}, v[2], v[3], v[4]), nil
+}
+
+// CheckValue returns an error when v doesn't fit in ValueBits bits, as
+// AssertIsValue requires
+func CheckValue(name string, v *fr.Element) error {
+	if v.BigInt(new(big.Int)).BitLen() > ValueBits {
+		return fmt.Errorf("%s doesn't fit in %d bits", name, ValueBits)
+	}
+	return nil
+}
+
+// CheckValues returns an error when the value T[1] doesn't fit in ValueBits
+// bits
+func (n *NativeNote) CheckValues() error {
+	return CheckValue("T[1]", &n.T[1])
+}
+
+// SerialNumber returns the serial number revealed when the note is spent
+func (n *NativeNote) SerialNumber() fr.Element {
+	k := NewNativeKeys(n.Sk)
+	return NativeSerialNumber(k.Nk, n.Rho)
+}
+
+// Full returns the note as a circuit assignment
+func (n *NativeNote) Full() NoteFull {
+	return NoteFull{
+		T:   [2]frontend.Variable{Variable(n.T[0]), Variable(n.T[1])},
+		Pk:  Variable(n.Pk),
+		Sk:  Variable(n.Sk),
+		G_d: twistededwards.Point{X: Variable(n.G_d.X), Y: Variable(n.G_d.Y)},
+		Rho: Variable(n.Rho),
+		R:   Variable(n.R),
+		Cm:  Variable(n.Cm),
+	}
+}
+
+// Note returns the note as a circuit assignment, without the owner's secret key
+func (n *NativeNote) Note() Note {
+	return Note{
+		T:   [2]frontend.Variable{Variable(n.T[0]), Variable(n.T[1])},
+		Pk:  Variable(n.Pk),
+		Rho: Variable(n.Rho),
+		R:   Variable(n.R),
+		Cm:  Variable(n.Cm),
+	}
+}
@@ -0,0 +1,27 @@
+package note
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+)
+
+func TestAssetID(t *testing.T) {
+	seen := make(map[fr.Element]string)
+	for _, name := range []string{"X", "\x00X", "\x00\x00X", "\x01X", "EUR-cent", "kWh-slot-42", strings.Repeat("\xff", fr.Bytes-2)} {
+		id, err := AssetID(name)
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if other, ok := seen[id]; ok {
+			t.Fatalf("%q and %q share an asset identifier", name, other)
+		}
+		seen[id] = name
+	}
+	for _, name := range []string{"", strings.Repeat("a", fr.Bytes-1)} {
+		if _, err := AssetID(name); err == nil {
+			t.Errorf("an asset name of %d bytes is accepted", len(name))
+		}
+	}
+}
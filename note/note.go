@@ -0,0 +1,186 @@
+// Package note holds the notes exchanged in the mechanism together with the
+// MiMC gadgets used by ProofReg, ProofDraw, ProofF and ProofTx:
+//
+//	Nk = H(Sk, 1), Ivk = H(Sk, 2), Dk = H(Sk, 3)
+//	G_d = [H(Dk, d)]G, Pk_d = [Ivk]G_d, Pk = H(G_d, Pk_d)
+//	Cm = H(T[0], T[1], R, Rho, Pk)
+//	Sn = H(Nk, Rho)
+//	Rho_j = H(Sn_1, ..., Sn_l, j)
+//
+// The spending key Sk is the only key able to spend a note. The nullifier key
+// Nk computes the serial numbers, and is the key handed over to the auctioneer
+// to spend a registered note. The incoming viewing key Ivk recognizes the notes
+// received, sent to one of the payment addresses (G_d, Pk_d) of Sk: the d-th
+// address has its own base G_d, drawn with the diversifier key Dk, so that two
+// addresses of the same keys can't be linked without Ivk. A note commits to the
+// address of its owner through Pk.
+//
+// A note created for someone else is encrypted to the address of its
+// recipient (see EncryptedNote). The Diffie-Hellman exchange runs on the
+// twisted Edwards curve of the addresses rather than on BLS12-377 G1: the
+// address is already a key pair on that curve, Pk_d = [Ivk]G_d, checked
+// in-circuit by ProofTx and ProofReg, so Ivk decrypts the notes received
+// without a second key pair, whereas a G1 key would be emulated in the
+// circuits over the scalar field of BLS12-377 at a much higher cost. The prime
+// order subgroup of the Edwards curve has a 251-bit order, about 125 bits of
+// security against discrete logarithms, on par with the 253-bit G1 subgroup.
+//
+// The rho of the j-th note created by a proof is derived from the serial
+// numbers of the l notes it spends, so that two notes never share a rho, and
+// thus a serial number, unless the ledger accepted the same serial number twice.
+// T[0] is the asset of the note, e.g. AssetID("EUR-cent"), and T[1] its value
+// in units of that asset. The amounts, T[1] of the notes and the bids, are
+// range checked to ValueBits bits by AssertIsValue.
+//
+// Every gadget has a native counterpart (see native.go) computing the same
+// value outside of the circuit.
+package note
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// ValueBits is the size of the amounts, so that sums and comparisons of
+// amounts never wrap around the modulus. Changing it changes the circuits.
+const ValueBits = 64
+
+type Note struct {
+	// asset T[0] and value T[1]
+	T [2]frontend.Variable
+	// Public key of the owner
+	Pk frontend.Variable
+	// Coin ID
+	Rho frontend.Variable
+	// Randomness used to generate the commitment
+	R frontend.Variable
+	// commitment of the coin
+	Cm frontend.Variable
+}
+
+type NoteFull struct {
+	// asset T[0] and value T[1]
+	T [2]frontend.Variable
+	// Public key of the owner
+	Pk frontend.Variable
+	// Spending key of the owner
+	Sk frontend.Variable
+	// base of the address of the owner
+	G_d twistededwards.Point
+	// Coin ID
+	Rho frontend.Variable
+	// Randomness used to generate the commitment
+	R frontend.Variable
+	// commitment of the coin
+	Cm frontend.Variable
+}
+
+// NullifierKey returns Nk = H(Sk, 1)
+func NullifierKey(api frontend.API, Sk frontend.Variable) frontend.Variable {
+	Nk_mimc, _ := mimc.NewMiMC(api)
+	Nk_mimc.Write(Sk)
+	Nk_mimc.Write(1)
+	return Nk_mimc.Sum()
+}
+
+// ViewingKey returns Ivk = H(Sk, 2)
+func ViewingKey(api frontend.API, Sk frontend.Variable) frontend.Variable {
+	Ivk_mimc, _ := mimc.NewMiMC(api)
+	Ivk_mimc.Write(Sk)
+	Ivk_mimc.Write(2)
+	return Ivk_mimc.Sum()
+}
+
+// KeyGen returns Pk = H(G_d, [Ivk]G_d), the public key of the address of base
+// G_d of the spending key Sk
+func KeyGen(api frontend.API, curve twistededwards.Curve, Sk frontend.Variable, G_d twistededwards.Point) frontend.Variable {
+	Pk_d := curve.ScalarMul(G_d, ViewingKey(api, Sk))
+	Keygen_mimc, _ := mimc.NewMiMC(api)
+	Keygen_mimc.Write(G_d.X, G_d.Y, Pk_d.X, Pk_d.Y)
+	return Keygen_mimc.Sum()
+}
+
+// Commitment returns Cm = H(T[0], T[1], R, Rho, Pk)
+func Commitment(api frontend.API, T [2]frontend.Variable, R, Rho, Pk frontend.Variable) frontend.Variable {
+	Cm_mimc, _ := mimc.NewMiMC(api)
+	Cm_mimc.Write(T[0])
+	Cm_mimc.Write(T[1])
+	Cm_mimc.Write(R)
+	Cm_mimc.Write(Rho)
+	Cm_mimc.Write(Pk)
+	return Cm_mimc.Sum()
+}
+
+// SerialNumber returns Sn = H(Nk, Rho)
+func SerialNumber(api frontend.API, Nk, Rho frontend.Variable) frontend.Variable {
+	Sn_mimc, _ := mimc.NewMiMC(api)
+	Sn_mimc.Write(Nk)
+	Sn_mimc.Write(Rho)
+	return Sn_mimc.Sum()
+}
+
+// DeriveRho returns Rho_j = H(Sn_1, ..., Sn_l, j), the rho of the j-th note
+// created by a proof spending the notes of serial numbers Sn
+func DeriveRho(api frontend.API, j int, Sn ...frontend.Variable) frontend.Variable {
+	Rho_mimc, _ := mimc.NewMiMC(api)
+	Rho_mimc.Write(Sn...)
+	Rho_mimc.Write(j)
+	return Rho_mimc.Sum()
+}
+
+// AssertIsValue asserts that each value fits in ValueBits bits
+func AssertIsValue(api frontend.API, values ...frontend.Variable) {
+	rc := rangecheck.New(api)
+	for _, v := range values {
+		rc.Check(v, ValueBits)
+	}
+}
+
+// AssertValues asserts that the value T[1] fits in ValueBits bits, the asset
+// T[0] being any field element
+func (n *Note) AssertValues(api frontend.API) {
+	AssertIsValue(api, n.T[1])
+}
+
+// AssertValues asserts that the value T[1] fits in ValueBits bits, the asset
+// T[0] being any field element
+func (n *NoteFull) AssertValues(api frontend.API) {
+	AssertIsValue(api, n.T[1])
+}
+
+// Commit computes the commitment of the note from its opening
+func (n *Note) Commit(api frontend.API) frontend.Variable {
+	return Commitment(api, n.T, n.R, n.Rho, n.Pk)
+}
+
+// Commit computes the commitment of the note from its opening
+func (n *NoteFull) Commit(api frontend.API) frontend.Variable {
+	return Commitment(api, n.T, n.R, n.Rho, n.Pk)
+}
+
+// SerialNumber computes the serial number revealed when the note is spent
+func (n *NoteFull) SerialNumber(api frontend.API) frontend.Variable {
+	return SerialNumber(api, NullifierKey(api, n.Sk), n.Rho)
+}
+
+// AssertOwner asserts that Sk is the spending key of the address of the note
+func (n *NoteFull) AssertOwner(api frontend.API, curve twistededwards.Curve) {
+	api.AssertIsEqual(n.Pk, KeyGen(api, curve, n.Sk, n.G_d))
+}
+
+// Note drops the secret key of the owner
+func (n *NoteFull) Note() Note {
+	return Note{T: n.T, Pk: n.Pk, Rho: n.Rho, R: n.R, Cm: n.Cm}
+}
+
+// Define a function that generates well defined notes to test the circuit
+func GenerateNotes() (NoteFull, error) {
+	//generate random secrets, T, Sk, Rho and R, and derive Pk and Cm
+	n, err := RandomNativeNote()
+	if err != nil {
+		return NoteFull{}, err
+	}
+	return n.Full(), nil
+}
@@ -1,97 +1,3 @@
-package main
-
-import (
-	"math/big"
-
-	bls12377_fp "github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
-	mimc_bw6_761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
-)
-
-type Coin struct {
-	// Value of the coin
-	V [48]byte
-	// Public key of the owner
-	Pk [48]byte
-	// Secret key of the owner
-	Sk [48]byte
-	// Coin ID
-	Rho [48]byte
-	// Randomness used to generate the commitment
-	R [48]byte
-	// Signature of the coin
-	Signature Signature
-}
-
-// Take a random Rho and R and set the coin's values and the coin' owner's public key
-func (coin *Coin) CreateCoinToMint(pk [48]byte, v big.Int) *Coin {
-
-	coin.V = new(bls12377_fp.Element).SetBigInt(&v).Bytes()
-	coin.Pk = pk
-
-	var rho_fp bls12377_fp.Element
-	rho_fp.SetRandom()
-	rho_bytes := rho_fp.Bytes()
-	copy(coin.Rho[:], rho_bytes[:])
-
-	var r_fp bls12377_fp.Element
-	r_fp.SetRandom()
-	r_bytes := r_fp.Bytes()
-	copy(coin.R[:], r_bytes[:])
-
-	return coin
-}
-
-// TODO: add the deterministic formula for rho
-func (coin *Coin) CreateCoinToPour(pk [48]byte, v big.Int) *Coin {
-	coin.V = new(bls12377_fp.Element).SetBigInt(&v).Bytes()
-	coin.Pk = pk
-
-	var rho_fp bls12377_fp.Element
-	rho_fp.SetRandom()
-	rho_bytes := rho_fp.Bytes()
-	copy(coin.Rho[:], rho_bytes[:])
-
-	var r_fp bls12377_fp.Element
-	r_fp.SetRandom()
-	r_bytes := r_fp.Bytes()
-	copy(coin.R[:], r_bytes[:])
-
-	return coin
-}
-
-func (c *Coin) CommitCoin() [48]byte {
-	if c.R == [48]byte{} {
-		// Chose a random R if not chosen already
-		var r_fp bls12377_fp.Element
-		r_fp.SetRandom()
-		r_bytes := r_fp.Bytes()
-		copy(c.R[:], r_bytes[:])
-	}
-	mimc := mimc_bw6_761.NewMiMC()
-	_, err := mimc.Write(c.V[:])
-	if err != nil {
-		panic(err)
-	}
-	_, err = mimc.Write(c.Pk[:])
-	if err != nil {
-		panic(err)
-	}
-	_, err = mimc.Write(c.Rho[:])
-	if err != nil {
-		panic(err)
-	}
-	_, err = mimc.Write(c.R[:])
-	if err != nil {
-		panic(err)
-	}
-	var res_buf []byte
-	res_buf = mimc.Sum(res_buf)
-	return [48]byte(res_buf)
-}
-
-
-///////////////////////////////////////
-
 package ps_threshold
 
 // Implementation of Pointcheval-Sanders signature scheme (https://eprint.iacr.org/2015/525.pdf)
@@ -353,9 +259,15 @@ func ThresholdAggregateSignatures(sigs []PartialSignature) Signature {
 		}
 	}
 
-	index_list := make([]scalar_field.Element, len(sigs))
+	index_list := make([]*big.Int, len(sigs))
 	for i := 0; i < len(sigs); i++ {
-		index_list[i] = sigs[i].Index
+		index_list[i] = sigs[i].Index.BigInt(new(big.Int))
+	}
+
+	// l_i, see LagrangeCoefficients
+	coefficients, err := LagrangeCoefficients(index_list, scalar_field.Modulus())
+	if err != nil {
+		panic(err)
 	}
 
 	// Sigma_2
@@ -363,41 +275,10 @@ func ThresholdAggregateSignatures(sigs []PartialSignature) Signature {
 
 	// Sigma = Sum(i)(l_i * sigma_i )
 	for i := 0; i < len(sigs); i++ {
-		// Compute Lagrange coefficient
-		// l1 = Product(j!=i)(1<j<t) (0-j)
-		// l2' = Product(j!=i)(1<j<t) (i-j)
-		// l2 = l2'^-1
-		// l_i = l1 + l2
-
-		l1 := scalar_field.One()
-		for j := 0; j < len(sigs); j++ {
-			if i != j {
-				var zero_scalar, j_scalar, sub scalar_field.Element
-				zero_scalar.SetZero()
-				j_scalar = index_list[j]
-				sub.Sub(&zero_scalar, &j_scalar)
-				l1.Mul(&l1, &sub)
-			}
-		}
-
-		l2 := scalar_field.One()
-		for j := 0; j < len(sigs); j++ {
-			if i != j {
-				var i_scalar, j_scalar, sub scalar_field.Element
-				i_scalar = index_list[i]
-				j_scalar = index_list[j]
-				sub.Sub(&i_scalar, &j_scalar)
-				l2.Mul(&l2, &sub)
-			}
-		}
-		l2.Inverse(&l2)
-		var l_i scalar_field.Element
-		l_i.Mul(&l1, &l2)
-
 		// l_i * sigma_i
 		var product curve.G1Affine
 		sigma_i := sigs[i].Sigma_2
-		product.ScalarMultiplication(&sigma_i, l_i.BigInt(new(big.Int)))
+		product.ScalarMultiplication(&sigma_i, coefficients[i])
 		// Sum with sigma_2
 		if i == 0 {
 			sigma_2 = product
@@ -448,4 +329,3 @@ func (pk *PublicKey) Verify(msg *scalar_field.Element, sigma_1 *curve.G1Affine,
 	}
 
 }
-
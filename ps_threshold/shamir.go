@@ -0,0 +1,69 @@
+package ps_threshold
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+////////////////////////////////////////////////////////
+// Shamir secret sharing modulo any prime
+////////////////////////////////////////////////////////
+
+// Shares splits a random secret t-of-n modulo the prime modulus: a random
+// polynomial of degree t-1 is evaluated at 0, 1, ..., n. As for
+// NewThresholdSecretKeys, n+1 values are returned because the first one is the
+// secret, and the i-th one is the share of index i.
+func Shares(t int, n int, modulus *big.Int) ([]*big.Int, error) {
+	if t < 1 || t > n {
+		return nil, fmt.Errorf("invalid threshold t=%d for n=%d", t, n)
+	}
+	coefficients := make([]*big.Int, t)
+	for i := range coefficients {
+		c, err := rand.Int(rand.Reader, modulus)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+	shares := make([]*big.Int, n+1)
+	for i := 0; i <= n; i++ {
+		// Horner's rule
+		x := big.NewInt(int64(i))
+		y := new(big.Int)
+		for j := t - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coefficients[j])
+			y.Mod(y, modulus)
+		}
+		shares[i] = y
+	}
+	return shares, nil
+}
+
+// LagrangeCoefficients returns the coefficients interpolating at 0 a
+// polynomial from its values at the indices, modulo the prime modulus
+//
+//	l_i = Product(j!=i) (0-j) * [Product(j!=i) (i-j)]^-1
+//
+// so that the secret is Sum(i)(l_i * share_i)
+func LagrangeCoefficients(indices []*big.Int, modulus *big.Int) ([]*big.Int, error) {
+	coefficients := make([]*big.Int, len(indices))
+	for i := range indices {
+		l1, l2 := big.NewInt(1), big.NewInt(1)
+		for j := range indices {
+			if i == j {
+				continue
+			}
+			l1.Mul(l1, new(big.Int).Neg(indices[j]))
+			l1.Mod(l1, modulus)
+			l2.Mul(l2, new(big.Int).Sub(indices[i], indices[j]))
+			l2.Mod(l2, modulus)
+		}
+		if l2.ModInverse(l2, modulus) == nil {
+			return nil, fmt.Errorf("the indices must be distinct")
+		}
+		coefficients[i] = l1.Mul(l1, l2).Mod(l1, modulus)
+	}
+	return coefficients, nil
+}
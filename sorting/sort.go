@@ -0,0 +1,185 @@
+// Package sorting implements the in-circuit sorting of an array of entries,
+// each one being a key with its payload (bidder index, quantity...).
+//
+// Rather than a sorting network, whose O(n log² n) comparators are too many
+// for the round sizes of the auction, the sorted array is computed by a hint
+// and checked in-circuit: the keys of the result must be in order, and the
+// result must be a permutation of the input.
+//
+// The keys are bounded: they and the differences between consecutive sorted
+// keys are range checked, which costs much less than comparisons of full field
+// elements. The sort is stable, and the circuit enforces it: each entry i is
+// moved with its index, and the entries are ordered by the unique key
+// key·2^idxBits + i (key·2^idxBits + n-1-i in decreasing order), whose
+// consecutive differences must be at least one. The prover therefore can't
+// choose the order of the entries of equal keys.
+//
+// The permutation is checked with a randomized product argument: each entry is
+// compressed into c = key + α·index + α²·payload[0] + α³·payload[1] + ... and
+// the products of (γ - c) over both arrays must be equal, α and γ being
+// derived from a commitment to both arrays.
+package sorting
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/multicommit"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+func init() {
+	solver.RegisterHint(sortHint)
+}
+
+// Entry is an element of the array to sort
+type Entry struct {
+	// key the entries are sorted by
+	Key frontend.Variable
+	// values moved with the key, every entry must have the same number
+	Payload []frontend.Variable
+}
+
+// Order of the sorted keys
+type Order int
+
+const (
+	Increasing Order = iota
+	Decreasing
+)
+
+// Sort returns the entries sorted by key in the given order, entries of equal
+// keys keeping their relative order. It asserts that every key fits in the
+// given number of bits.
+func Sort(api frontend.API, entries []Entry, order Order, keyBits int) ([]Entry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if order != Increasing && order != Decreasing {
+		return nil, fmt.Errorf("invalid order %d", order)
+	}
+	// the sum of a unique key and a difference must not wrap around the
+	// modulus
+	n := len(entries)
+	idxBits := bits.Len(uint(n - 1))
+	if keyBits < 1 || keyBits+idxBits >= api.Compiler().FieldBitLen()-1 {
+		return nil, fmt.Errorf("invalid key size of %d bits for %d entries", keyBits, n)
+	}
+	width := len(entries[0].Payload)
+
+	// hint inputs: order, width, then each entry as key, payload...
+	inputs := []frontend.Variable{int(order), width}
+	for i := range entries {
+		if len(entries[i].Payload) != width {
+			return nil, fmt.Errorf("entry %d has %d payload values, expected %d", i, len(entries[i].Payload), width)
+		}
+		inputs = append(inputs, entries[i].Key)
+		inputs = append(inputs, entries[i].Payload...)
+	}
+	// outputs: each sorted entry as key, index, payload...
+	outputs, err := api.Compiler().NewHint(sortHint, n*(2+width), inputs...)
+	if err != nil {
+		return nil, err
+	}
+	indexed := make([]Entry, n)
+	sorted := make([]Entry, n)
+	for i := range sorted {
+		indexed[i] = Entry{Key: entries[i].Key, Payload: append([]frontend.Variable{i}, entries[i].Payload...)}
+		row := outputs[i*(2+width) : (i+1)*(2+width)]
+		sorted[i] = Entry{Key: row[0], Payload: row[2:]}
+	}
+
+	// the keys are bounded, and the unique keys of the sorted entries are
+	// strictly in order
+	rc := rangecheck.New(api)
+	for i := range entries {
+		rc.Check(entries[i].Key, keyBits)
+	}
+	unique := func(i int) frontend.Variable {
+		key, index := outputs[i*(2+width)], outputs[i*(2+width)+1]
+		if order == Decreasing {
+			index = api.Sub(n-1, index)
+		}
+		return api.Add(api.Mul(key, new(big.Int).Lsh(big.NewInt(1), uint(idxBits))), index)
+	}
+	for i := 1; i < n; i++ {
+		if order == Increasing {
+			rc.Check(api.Sub(unique(i), unique(i-1), 1), keyBits+idxBits)
+		} else {
+			rc.Check(api.Sub(unique(i-1), unique(i), 1), keyBits+idxBits)
+		}
+	}
+
+	// sorted is a permutation of entries
+	multicommit.WithCommitment(api, func(api frontend.API, gamma frontend.Variable) error {
+		alpha_mimc, err := mimc.NewMiMC(api)
+		if err != nil {
+			return err
+		}
+		alpha_mimc.Write(gamma)
+		alpha := alpha_mimc.Sum()
+
+		var left, right frontend.Variable = 1, 1
+		for i := range entries {
+			row := outputs[i*(2+width) : (i+1)*(2+width)]
+			left = api.Mul(left, api.Sub(gamma, compress(api, alpha, indexed[i])))
+			right = api.Mul(right, api.Sub(gamma, compress(api, alpha, Entry{Key: row[0], Payload: row[1:]})))
+		}
+		api.AssertIsEqual(left, right)
+		return nil
+	}, append(inputs[2:], outputs...)...)
+
+	return sorted, nil
+}
+
+// compress returns key + α·payload[0] + α²·payload[1] + ...
+func compress(api frontend.API, alpha frontend.Variable, e Entry) frontend.Variable {
+	res := e.Key
+	var power frontend.Variable = 1
+	for _, v := range e.Payload {
+		power = api.Mul(power, alpha)
+		res = api.Add(res, api.Mul(power, v))
+	}
+	return res
+}
+
+// sortHint sorts the entries given as inputs, and returns each one with its
+// index in the inputs, see Sort
+func sortHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 2 {
+		return fmt.Errorf("sortHint expects the order and the payload width")
+	}
+	order, width := inputs[0].Int64(), int(inputs[1].Int64())
+	rows := inputs[2:]
+	if width < 0 || len(rows)%(1+width) != 0 {
+		return fmt.Errorf("sortHint got %d inputs for a payload width of %d", len(rows), width)
+	}
+	n := len(rows) / (1 + width)
+	if len(outputs) != n*(2+width) {
+		return fmt.Errorf("sortHint got %d outputs for %d entries of payload width %d", len(outputs), n, width)
+	}
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		c := rows[indices[a]*(1+width)].Cmp(rows[indices[b]*(1+width)])
+		if order == int64(Decreasing) {
+			return c > 0
+		}
+		return c < 0
+	})
+	for i, j := range indices {
+		outputs[i*(2+width)].Set(rows[j*(1+width)])
+		outputs[i*(2+width)+1].SetInt64(int64(j))
+		for k := 1; k <= width; k++ {
+			outputs[i*(2+width)+1+k].Set(rows[j*(1+width)+k])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package sorting
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+// sortCircuit sorts the keys with their payload, and asserts the result when
+// check is set
+type sortCircuit struct {
+	Keys    []frontend.Variable
+	Payload []frontend.Variable
+	Sorted  []frontend.Variable
+	Moved   []frontend.Variable
+	order   Order
+	check   bool
+}
+
+func (c *sortCircuit) Define(api frontend.API) error {
+	entries := make([]Entry, len(c.Keys))
+	for i := range entries {
+		entries[i] = Entry{Key: c.Keys[i], Payload: []frontend.Variable{c.Payload[i]}}
+	}
+	sorted, err := Sort(api, entries, c.order, 8)
+	if err != nil {
+		return err
+	}
+	if c.check {
+		for i := range sorted {
+			api.AssertIsEqual(sorted[i].Key, c.Sorted[i])
+			api.AssertIsEqual(sorted[i].Payload[0], c.Moved[i])
+		}
+	}
+	return nil
+}
+
+func newSortCircuit(n int, order Order, check bool) *sortCircuit {
+	return &sortCircuit{
+		Keys:    make([]frontend.Variable, n),
+		Payload: make([]frontend.Variable, n),
+		Sorted:  make([]frontend.Variable, n),
+		Moved:   make([]frontend.Variable, n),
+		order:   order,
+		check:   check,
+	}
+}
+
+func assignSort(keys, payload, sorted, moved []int) *sortCircuit {
+	c := newSortCircuit(len(keys), Increasing, true)
+	for i := range keys {
+		c.Keys[i], c.Payload[i], c.Sorted[i], c.Moved[i] = keys[i], payload[i], sorted[i], moved[i]
+	}
+	return c
+}
+
+func TestSortStable(t *testing.T) {
+	keys := []int{5, 3, 5, 1, 3}
+	payload := []int{10, 11, 12, 13, 14}
+	field := ecc.BLS12_377.ScalarField()
+
+	// ties keep their relative order in both orders
+	if err := test.IsSolved(newSortCircuit(5, Increasing, true), assignSort(keys, payload, []int{1, 3, 3, 5, 5}, []int{13, 11, 14, 10, 12}), field); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(newSortCircuit(5, Decreasing, true), assignSort(keys, payload, []int{5, 5, 3, 3, 1}, []int{10, 12, 11, 14, 13}), field); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(newSortCircuit(5, Increasing, true), assignSort(keys, payload, []int{1, 3, 3, 5, 5}, []int{13, 14, 11, 10, 12}), field); err == nil {
+		t.Fatal("swapped ties are accepted")
+	}
+}
+
+// TestSortProver replaces the hint by a dishonest prover, whose output must be
+// rejected unless it is the stable sort of the entries
+func TestSortProver(t *testing.T) {
+	keys := []int{5, 3, 5, 1, 3}
+	ccs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), r1cs.NewBuilder, newSortCircuit(len(keys), Increasing, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment := newSortCircuit(len(keys), Increasing, false)
+	for i := range keys {
+		assignment.Keys[i], assignment.Payload[i] = keys[i], 10+i
+		assignment.Sorted[i], assignment.Moved[i] = 0, 0
+	}
+	w, err := frontend.NewWitness(assignment, ecc.BLS12_377.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each prover outputs the entries of the given indices
+	for _, tc := range []struct {
+		name    string
+		indices []int
+		valid   bool
+	}{
+		{"stable", []int{3, 1, 4, 0, 2}, true},
+		{"swapped ties", []int{3, 4, 1, 0, 2}, false},
+		{"unsorted", []int{1, 3, 4, 0, 2}, false},
+		{"not a permutation", []int{3, 1, 1, 0, 2}, false},
+	} {
+		prover := func(_ *big.Int, inputs, outputs []*big.Int) error {
+			rows := inputs[2:]
+			for i, j := range tc.indices {
+				outputs[3*i].Set(rows[2*j])
+				outputs[3*i+1].SetInt64(int64(j))
+				outputs[3*i+2].Set(rows[2*j+1])
+			}
+			return nil
+		}
+		err := ccs.IsSolved(w, solver.OverrideHint(solver.GetHintID(sortHint), prover))
+		if tc.valid && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: the sort is accepted", tc.name)
+		}
+	}
+}
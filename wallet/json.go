@@ -0,0 +1,51 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Save writes the state of the wallet to the file, replacing it at once so
+// that a crash never leaves a truncated wallet behind. The file holds the
+// spending key: it must be kept private.
+func (w *Wallet) Save(path string) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the state of a wallet written by Save
+func Load(path string) (*Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var w Wallet
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if w.Tree == nil {
+		return nil, fmt.Errorf("%s: the wallet has no merkle tree", path)
+	}
+	return &w, nil
+}
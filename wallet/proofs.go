@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofdraw"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofreg"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooftx"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// Transfer fills the assignment of ProofTx paying amount of the asset to the
+// address, and the fee to the operator, for the auctioneer of key g_b. The
+// notes spent are chosen by Select, and the change goes back to a new address
+// of the wallet. The circuit spends as many notes as selected and creates two:
+// the payment then the change. The encrypted new notes are published with the
+// proof. The notes spent aren't selected again until they are nullified, or
+// released if the proof is dropped.
+func (w *Wallet) Transfer(asset, amount, fee fr.Element, to note.NativeAddress, G_b edwards.PointAffine) (*prooftx.RegisterCircuit, []note.EncryptedNote, error) {
+	var total fr.Element
+	total.Add(&amount, &fee)
+	notes, change, err := w.Select(asset, total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := witness.Transfer{Rt: w.Tree.Root(), Asset_pub: asset, Fee: fee, G_b: G_b}
+	for _, n := range notes {
+		t.Old = append(t.Old, n.Note)
+		t.Witnesses = append(t.Witnesses, n.Witness)
+	}
+	// the rho of the new notes is derived by ProofTx
+	var R [2]fr.Element
+	for i := range R {
+		if _, err := R[i].SetRandom(); err != nil {
+			return nil, nil, err
+		}
+	}
+	_, d := w.next()
+	t.New = []note.NativeNote{
+		note.NewNativeNoteTo([2]fr.Element{asset, amount}, to, fr.Element{}, R[0]),
+		note.NewNativeNoteAt([2]fr.Element{asset, change}, w.Sk, d, fr.Element{}, R[1]),
+	}
+	// the transfer carries no bid, b is zero
+	if t.R, t.G, err = encryption(); err != nil {
+		return nil, nil, err
+	}
+
+	assignment, err := t.ProofTx()
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs, err := t.Outputs()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.Addresses++
+	for _, n := range notes {
+		n.Spending = true
+	}
+	return assignment, outputs, nil
+}
+
+// Register fills the assignment of ProofReg registering the note of commitment
+// cm with the bid, for the auctioneer of key g_b. The note is locked until it
+// is spent by ProofDraw or ProofF, which give it back to a new address of the
+// wallet.
+func (w *Wallet) Register(cm fr.Element, b bid.NativeBid, G_b edwards.PointAffine) (*proofreg.RegisterCircuit, error) {
+	n := w.find(cm)
+	if n == nil || n.Spent || n.Registered {
+		return nil, fmt.Errorf("the note isn't an unspent note of the wallet")
+	}
+	var s witness.Secrets
+	var err error
+	if s.R, s.G, err = encryption(); err != nil {
+		return nil, err
+	}
+	s.N_in, s.Bid, s.G_b = n.Note, b, G_b
+	var R fr.Element
+	if _, err := R.SetRandom(); err != nil {
+		return nil, err
+	}
+	_, d := w.next()
+	s.N_out = note.NewNativeNoteAt(n.Note.T, w.Sk, d, fr.Element{}, R)
+	s.DeriveOut()
+
+	assignment, err := s.ProofReg()
+	if err != nil {
+		return nil, err
+	}
+	w.Addresses++
+	n.Registered = true
+	w.Registrations = append(w.Registrations, s)
+	w.Pending = append(w.Pending, s.N_out)
+	return assignment, nil
+}
+
+// Draw fills the assignment of ProofDraw withdrawing the bid registered with
+// the note of commitment cm
+func (w *Wallet) Draw(cm fr.Element) (*proofdraw.RegisterCircuit, error) {
+	for i := range w.Registrations {
+		if s := &w.Registrations[i]; s.N_in.Cm.Equal(&cm) {
+			return s.ProofDraw()
+		}
+	}
+	return nil, fmt.Errorf("the note isn't registered")
+}
+
+// Settle replaces the note given back for the bid registered with the note of
+// commitment cm by the one created by ProofF, given the payment published for
+// the wallet, so that it is owned once its commitment is appended
+func (w *Wallet) Settle(cm, payment fr.Element) error {
+	for i := range w.Registrations {
+		s := &w.Registrations[i]
+		if !s.N_in.Cm.Equal(&cm) {
+			continue
+		}
+		settled, err := s.Settle(payment)
+		if err != nil {
+			return err
+		}
+		for j := range w.Pending {
+			if w.Pending[j].Cm.Equal(&s.N_out.Cm) {
+				w.Pending[j] = settled.N_out
+			}
+		}
+		*s = settled
+		return nil
+	}
+	return fmt.Errorf("the note isn't registered")
+}
+
+// encryption draws the randomness r encrypting the keys for the auctioneer,
+// for the base point g of the twisted Edwards curve of BLS12-377
+func encryption() (R fr.Element, G edwards.PointAffine, err error) {
+	curve := edwards.GetEdwardsCurve()
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(&curve.Order, big.NewInt(1)))
+	if err != nil {
+		return
+	}
+	R.SetBigInt(r.Add(r, big.NewInt(1)))
+	G = curve.Base
+	return
+}
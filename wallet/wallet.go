@@ -0,0 +1,257 @@
+// Package wallet keeps the state of a participant: its spending key, the notes
+// it owns with their position in the merkle tree of the commitments, and
+// whether they are spent.
+//
+// The wallet follows the ledger: every commitment is appended to its own copy
+// of the tree (Append), the encrypted notes published with ProofTx are trial
+// decrypted with its incoming viewing key (Receive), and the serial numbers
+// revealed by the proofs mark its notes spent (Nullify). From that state it
+// selects the notes to spend and fills the assignments of ProofTx, ProofReg
+// and ProofDraw (see proofs.go). The state is saved to a JSON file (see
+// json.go).
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/merkle"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// Wallet is the state of a participant
+type Wallet struct {
+	// spending key, from which every other key is derived
+	Sk fr.Element
+	// number of addresses handed out, the next one having this index
+	Addresses uint64
+	// merkle tree of the commitments of the ledger
+	Tree *merkle.Tree
+	// notes received, spent or not
+	Notes []Note
+	// notes created by the wallet for itself, owned once their commitment is
+	// appended
+	Pending []note.NativeNote
+	// secrets of the bids registered, until the note is spent
+	Registrations []witness.Secrets
+}
+
+// Note is a note owned by the wallet
+type Note struct {
+	Note note.NativeNote
+	// authentication path of the commitment, up to date with the tree
+	Witness merkle.Witness
+	// serial number revealed when the note is spent
+	Sn fr.Element
+	// true once the serial number is revealed
+	Spent bool
+	// true while the note is registered for an auction, so that it isn't
+	// selected for a transfer
+	Registered bool
+	// true once the note is spent by a transfer whose serial numbers aren't
+	// revealed yet, so that it isn't selected again (see Release)
+	Spending bool
+}
+
+// New returns an empty wallet of a random spending key, following a tree of
+// commitments of the given depth
+func New(depth int) (*Wallet, error) {
+	tree, err := merkle.NewTree(depth)
+	if err != nil {
+		return nil, err
+	}
+	w := &Wallet{Tree: tree}
+	if _, err := w.Sk.SetRandom(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Keys returns the keys of the wallet
+func (w *Wallet) Keys() note.NativeKeys {
+	return note.NewNativeKeys(w.Sk)
+}
+
+// Address returns a new address of the wallet, which can't be linked to the
+// previous ones without the incoming viewing key
+func (w *Wallet) Address() (note.NativeAddress, uint64) {
+	a, d := w.next()
+	w.Addresses++
+	return a, d
+}
+
+// next returns the address handed out by the next call to Address
+func (w *Wallet) next() (note.NativeAddress, uint64) {
+	k := w.Keys()
+	return k.Address(w.Addresses), w.Addresses
+}
+
+// Append appends the commitment of a note to the tree, as the ledger does. The
+// note is owned when the wallet created it for itself.
+func (w *Wallet) Append(cm fr.Element) error {
+	if _, err := w.Tree.Append(cm); err != nil {
+		return err
+	}
+	for i := range w.Notes {
+		if !w.Notes[i].Spent {
+			if err := w.Notes[i].Witness.Update(w.Tree); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range w.Pending {
+		if w.Pending[i].Cm.Equal(&cm) {
+			n := w.Pending[i]
+			w.Pending = append(w.Pending[:i], w.Pending[i+1:]...)
+			return w.own(n)
+		}
+	}
+	return nil
+}
+
+// Receive appends the commitments of the encrypted notes to the tree, and owns
+// those sent to an address of the wallet. The notes are checked before the
+// tree changes, so that a call which fails can be retried.
+func (w *Wallet) Receive(notes ...note.EncryptedNote) error {
+	if room := uint64(1)<<w.Tree.Depth() - w.Tree.Size(); uint64(len(notes)) > room {
+		return fmt.Errorf("the merkle tree has room for %d commitments, got %d", room, len(notes))
+	}
+	k := w.Keys()
+	owned := make([]*note.NativeNote, len(notes))
+	seen := make(map[fr.Element]bool)
+	for i := range notes {
+		n, ok := note.TrialDecrypt(k.Ivk, &notes[i])
+		if !ok || seen[n.Cm] || w.find(n.Cm) != nil {
+			continue
+		}
+		// the spending key and the index of the address complete the note
+		d, err := w.index(n.Address())
+		if err != nil {
+			return fmt.Errorf("encrypted note %d: %w", i, err)
+		}
+		o := note.NewNativeNoteAt(n.T, w.Sk, d, n.Rho, n.R)
+		owned[i], seen[n.Cm] = &o, true
+	}
+
+	for i := range notes {
+		if err := w.Append(notes[i].Cm); err != nil {
+			return err
+		}
+		if owned[i] != nil {
+			if err := w.own(*owned[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Nullify marks spent the notes whose serial numbers are revealed, and forgets
+// the registrations they were locked by
+func (w *Wallet) Nullify(sns ...fr.Element) {
+	for _, sn := range sns {
+		for i := range w.Notes {
+			if n := &w.Notes[i]; n.Sn.Equal(&sn) {
+				n.Spent, n.Registered, n.Spending = true, false, false
+				w.unregister(n.Note.Cm)
+			}
+		}
+	}
+}
+
+// Balance returns the total value of the unspent notes of the asset
+func (w *Wallet) Balance(asset fr.Element) fr.Element {
+	var res fr.Element
+	for i := range w.Notes {
+		if n := &w.Notes[i]; !n.Spent && n.Note.T[0].Equal(&asset) {
+			res.Add(&res, &n.Note.T[1])
+		}
+	}
+	return res
+}
+
+// Release makes the notes of the commitments selectable again, when the
+// transfer spending them is dropped before their serial numbers are revealed
+func (w *Wallet) Release(cms ...fr.Element) {
+	for _, cm := range cms {
+		if n := w.find(cm); n != nil {
+			n.Spending = false
+		}
+	}
+}
+
+// Select returns unspent notes of the asset, neither registered nor spent by a
+// pending transfer, whose values sum up to at least amount, largest first, and
+// the change left
+func (w *Wallet) Select(asset, amount fr.Element) ([]*Note, fr.Element, error) {
+	var candidates []*Note
+	for i := range w.Notes {
+		if n := &w.Notes[i]; !n.Spent && !n.Registered && !n.Spending && n.Note.T[0].Equal(&asset) {
+			candidates = append(candidates, n)
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].Note.T[1].Cmp(&candidates[b].Note.T[1]) > 0
+	})
+
+	target := amount.BigInt(new(big.Int))
+	total := new(big.Int)
+	for i, n := range candidates {
+		if total.Cmp(target) >= 0 && i > 0 {
+			candidates = candidates[:i]
+			break
+		}
+		total.Add(total, n.Note.T[1].BigInt(new(big.Int)))
+	}
+	var change fr.Element
+	if len(candidates) == 0 || total.Cmp(target) < 0 {
+		return nil, change, fmt.Errorf("insufficient funds: %s available for %s", total.String(), target.String())
+	}
+	change.SetBigInt(total.Sub(total, target))
+	return candidates, change, nil
+}
+
+// own records the note, whose commitment is the last one appended
+func (w *Wallet) own(n note.NativeNote) error {
+	wit, err := w.Tree.Witness(n.Cm)
+	if err != nil {
+		return err
+	}
+	w.Notes = append(w.Notes, Note{Note: n, Witness: wit, Sn: n.SerialNumber()})
+	return nil
+}
+
+// find returns the note of commitment cm, nil when the wallet doesn't own it
+func (w *Wallet) find(cm fr.Element) *Note {
+	for i := range w.Notes {
+		if w.Notes[i].Note.Cm.Equal(&cm) {
+			return &w.Notes[i]
+		}
+	}
+	return nil
+}
+
+// index returns the index of the address of the wallet
+func (w *Wallet) index(a note.NativeAddress) (uint64, error) {
+	k := w.Keys()
+	for d := uint64(0); d < w.Addresses; d++ {
+		if b := k.Address(d); b.G_d.Equal(&a.G_d) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("the address wasn't handed out by the wallet")
+}
+
+// unregister forgets the registration of the note of commitment cm
+func (w *Wallet) unregister(cm fr.Element) {
+	for i := range w.Registrations {
+		if w.Registrations[i].N_in.Cm.Equal(&cm) {
+			w.Registrations = append(w.Registrations[:i], w.Registrations[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package wallet
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/witness"
+)
+
+// sendTo returns a random note encrypted to the address
+func sendTo(t *testing.T, a note.NativeAddress) note.EncryptedNote {
+	t.Helper()
+	n, err := note.RandomNativeNote()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n = note.NewNativeNoteTo(n.T, a, n.Rho, n.R)
+	e, err := n.Encrypt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestReceiveRetry(t *testing.T) {
+	w, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := w.Address()
+	other, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := other.Address()
+	// the third address of the wallet isn't handed out yet
+	k := w.Keys()
+	unknown := k.Address(2)
+	notes := []note.EncryptedNote{sendTo(t, a), sendTo(t, b), sendTo(t, unknown)}
+
+	if err := w.Receive(notes...); err == nil {
+		t.Fatal("a note sent to an address not handed out is received")
+	}
+	if w.Tree.Size() != 0 || len(w.Notes) != 0 {
+		t.Fatalf("the failed call changed the wallet: %d commitments and %d notes", w.Tree.Size(), len(w.Notes))
+	}
+
+	// once the address is handed out, the retry owns both notes of the wallet
+	w.Address()
+	w.Address()
+	if err := w.Receive(notes...); err != nil {
+		t.Fatal(err)
+	}
+	if w.Tree.Size() != 3 || len(w.Notes) != 2 {
+		t.Fatalf("got %d commitments and %d notes, expected 3 and 2", w.Tree.Size(), len(w.Notes))
+	}
+	root := w.Tree.Root()
+	for i := range w.Notes {
+		if r := w.Notes[i].Witness.Root(); !r.Equal(&root) || !w.Notes[i].Witness.Leaf.Equal(&w.Notes[i].Note.Cm) {
+			t.Fatalf("the witness of note %d doesn't authenticate it", i)
+		}
+	}
+
+	// a tree without room for the notes is left unchanged
+	full, err := New(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := full.Receive(notes...); err == nil || full.Tree.Size() != 0 {
+		t.Fatal("the notes are appended to a tree without room for them")
+	}
+}
+
+// fund has the wallet receive notes of the asset of the given values
+func fund(t *testing.T, w *Wallet, asset fr.Element, values ...*big.Int) {
+	t.Helper()
+	var notes []note.EncryptedNote
+	for _, v := range values {
+		a, _ := w.Address()
+		n, err := note.RandomNativeNote()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var value fr.Element
+		value.SetBigInt(v)
+		n = note.NewNativeNoteTo([2]fr.Element{asset, value}, a, n.Rho, n.R)
+		e, err := n.Encrypt()
+		if err != nil {
+			t.Fatal(err)
+		}
+		notes = append(notes, e)
+	}
+	if err := w.Receive(notes...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferSelection(t *testing.T) {
+	w, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, G_b, err := witness.RandomKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset, err := note.AssetID("EUR-cent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two notes of 2^63 + 1
+	value := new(big.Int).Lsh(big.NewInt(1), 63)
+	value.Add(value, big.NewInt(1))
+	fund(t, w, asset, value, value)
+	to, _ := w.Address()
+	addresses := w.Addresses
+
+	// a fee out of range fails the proof, without handing out an address or
+	// locking the notes
+	var amount, fee fr.Element
+	amount.SetOne()
+	fee.SetBigInt(new(big.Int).Lsh(big.NewInt(1), 64))
+	if _, _, err := w.Transfer(asset, amount, fee, to, G_b); err == nil {
+		t.Fatal("a transfer of a fee out of range is proven")
+	}
+	if w.Addresses != addresses {
+		t.Fatalf("the failed transfer handed out %d addresses", w.Addresses-addresses)
+	}
+
+	// each transfer spends one of the notes, which isn't selected again
+	fee.SetZero()
+	for range 2 {
+		if _, _, err := w.Transfer(asset, amount, fee, to, G_b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Addresses != addresses+2 {
+		t.Fatalf("the transfers handed out %d addresses, expected 2", w.Addresses-addresses)
+	}
+	if _, _, err := w.Transfer(asset, amount, fee, to, G_b); err == nil {
+		t.Fatal("a note spent by a pending transfer is selected again")
+	}
+
+	// a dropped transfer releases its notes
+	w.Release(w.Notes[0].Note.Cm)
+	if _, _, err := w.Transfer(asset, amount, fee, to, G_b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWithoutTree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	w, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+	w.Tree = nil
+	if err := w.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("a wallet without a merkle tree is loaded")
+	}
+}
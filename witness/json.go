@@ -0,0 +1,103 @@
+package witness
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// The secrets are exchanged in JSON, each field element being a decimal
+// number. Only T, Sk, D, Rho and R are read for each note: Pk, G_d and Cm are
+// derived from them, and the rho of N_out from the serial number of N_in. A
+// note sent to someone else has no Sk, and keeps the Pk and G_d read. When N_in
+// has no Sk, as in the secrets decrypted by the auctioneer, its serial number
+// is derived from Nk_in.
+
+// ReadSecrets decodes the secrets of a participant
+func ReadSecrets(r io.Reader) (Secrets, error) {
+	var s Secrets
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return s, err
+	}
+	s.N_in = derive(s.N_in)
+	s.DeriveOut()
+	return s, checkPoints(&s.G, &s.G_b)
+}
+
+// ReadTransfer decodes the secrets of a ProofTx
+func ReadTransfer(r io.Reader) (Transfer, error) {
+	var t Transfer
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return t, err
+	}
+	for i := range t.Old {
+		t.Old[i] = derive(t.Old[i])
+	}
+	for j := range t.New {
+		t.New[j] = derive(t.New[j])
+	}
+	return t, checkPoints(&t.G, &t.G_b)
+}
+
+// ReadRound decodes the secrets of the participants of a ProofF
+func ReadRound(r io.Reader) (Round, error) {
+	var round Round
+	if err := json.NewDecoder(r).Decode(&round); err != nil {
+		return round, err
+	}
+	for _, s := range round.participants() {
+		s.N_in = derive(s.N_in)
+		s.DeriveOut()
+		if err := checkPoints(&s.G, &s.G_b); err != nil {
+			return round, err
+		}
+	}
+	return round, round.check()
+}
+
+// WriteJSON encodes the secrets read by ReadSecrets
+func (s *Secrets) WriteJSON(w io.Writer) error {
+	return writeJSON(w, s)
+}
+
+// WriteJSON encodes the secrets read by ReadTransfer
+func (t *Transfer) WriteJSON(w io.Writer) error {
+	return writeJSON(w, t)
+}
+
+// WriteJSON encodes the secrets read by ReadRound
+func (r *Round) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
+
+// writeJSON encodes v, which must be a pointer for the field elements to be
+// written as numbers
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// derive recomputes the address and the commitment of the note
+func derive(n note.NativeNote) note.NativeNote {
+	if n.Sk.IsZero() {
+		n.Cm = note.NativeCommitment(n.T, n.R, n.Rho, n.Pk)
+		return n
+	}
+	return note.NewNativeNoteAt(n.T, n.Sk, n.D, n.Rho, n.R)
+}
+
+// checkPoints ensures g and g_b are points of the prime order subgroup of the
+// twisted Edwards curve of BLS12-377
+func checkPoints(points ...*edwards.PointAffine) error {
+	for _, p := range points {
+		if !note.InSubgroup(p) {
+			return fmt.Errorf("g and g_b must be points of the prime order subgroup of the twisted Edwards curve of BLS12-377")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package witness
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/auction"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooff"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// Round gathers the secrets of the participants of an auction round, decrypted
+// by the auctioneer. They share the generator g and the auctioneer key g_b, and
+// bid for the same delivery slot, on the buy side for the buyers and the sell
+// side for the sellers. Their notes N_in are of the same asset, in which the
+// payments are made, and their notes N_out are those given back by ProofDraw:
+// ProofF settles them (see Settle).
+type Round struct {
+	// pricing rule, as parsed by auction.ParseRule
+	Rule    string
+	Buyers  []Secrets
+	Sellers []Secrets
+}
+
+// RandomRound draws the secrets of the given number of buyers and sellers of a
+// round cleared under the rule, for the slot and the asset of the first buyer.
+// The note of each buyer pays for its whole bid.
+func RandomRound(buyers, sellers int, rule string) (Round, error) {
+	r := Round{Rule: rule}
+	if buyers < 1 || sellers < 1 {
+		return r, fmt.Errorf("invalid round shape buyers=%d sellers=%d", buyers, sellers)
+	}
+	if _, err := auction.ParseRule(rule); err != nil {
+		return r, err
+	}
+	r.Buyers = make([]Secrets, buyers)
+	r.Sellers = make([]Secrets, sellers)
+	for k, s := range r.participants() {
+		var err error
+		if *s, err = RandomSecrets(); err != nil {
+			return r, err
+		}
+		s.G, s.G_b = r.Buyers[0].G, r.Buyers[0].G_b
+		side := uint64(bid.Sell)
+		if k < buyers {
+			side = bid.Buy
+		}
+		if s.Bid, err = bid.RandomNativeBid(side, r.Buyers[0].Bid.Slot.Uint64()); err != nil {
+			return r, err
+		}
+		value := s.N_in.T[1]
+		if side == bid.Buy {
+			// the bid costs less than 2^(ValueBits-2), and the value of the
+			// note less than 2^63, so that it fits in ValueBits bits
+			cost := s.Bid.Cost()
+			value.Add(&value, &cost)
+		}
+		s.fund(r.Buyers[0].N_in.T[0], value)
+	}
+	return r, nil
+}
+
+// fund sets the asset and the value of N_in, and of the note N_out given back
+// by ProofDraw
+func (s *Secrets) fund(asset, value fr.Element) {
+	s.N_in = note.NewNativeNoteAt([2]fr.Element{asset, value}, s.N_in.Sk, s.N_in.D, s.N_in.Rho, s.N_in.R)
+	s.N_out.T = s.N_in.T
+	s.DeriveOut()
+}
+
+// participants returns the buyers then the sellers
+func (r *Round) participants() []*Secrets {
+	res := make([]*Secrets, 0, len(r.Buyers)+len(r.Sellers))
+	for i := range r.Buyers {
+		res = append(res, &r.Buyers[i])
+	}
+	for j := range r.Sellers {
+		res = append(res, &r.Sellers[j])
+	}
+	return res
+}
+
+// check ensures the round has buyers and sellers sharing g, g_b and the asset
+// of their notes, whose bids are on their side for the slot of the round
+func (r *Round) check() error {
+	if len(r.Buyers) == 0 || len(r.Sellers) == 0 {
+		return fmt.Errorf("the round needs buyers and sellers, got %d and %d", len(r.Buyers), len(r.Sellers))
+	}
+	G, G_b := r.Buyers[0].G, r.Buyers[0].G_b
+	slot := r.Buyers[0].Bid.Slot
+	asset := r.Buyers[0].N_in.T[0]
+	for k, s := range r.participants() {
+		if !s.G.Equal(&G) || !s.G_b.Equal(&G_b) {
+			return fmt.Errorf("the participants of a round must share g and g_b")
+		}
+		if !s.Bid.Slot.Equal(&slot) {
+			return fmt.Errorf("the bids of a round must share the delivery slot")
+		}
+		if !s.N_in.T[0].Equal(&asset) {
+			return fmt.Errorf("the notes of a round must share the asset")
+		}
+		side := uint64(bid.Sell)
+		if k < len(r.Buyers) {
+			side = bid.Buy
+		}
+		if !s.Bid.Side.IsUint64() || s.Bid.Side.Uint64() != side {
+			return fmt.Errorf("the buyers must bid on the buy side and the sellers on the sell side")
+		}
+	}
+	return nil
+}
+
+// Clearing computes the clearing of the bids of the round, the steps of the
+// j-th bid of a side being the bids j·bid.Steps, j·bid.Steps+1, ... of the
+// auction
+func (r *Round) Clearing() (auction.Clearing, error) {
+	rule, err := auction.ParseRule(r.Rule)
+	if err != nil {
+		return auction.Clearing{}, err
+	}
+	return auction.Clear(steps(r.Buyers), steps(r.Sellers), rule)
+}
+
+// steps returns the steps of the bids as bids of the auction
+func steps(participants []Secrets) []auction.NativeBid {
+	res := make([]auction.NativeBid, 0, len(participants)*bid.Steps)
+	for i := range participants {
+		for _, step := range participants[i].Bid.Steps {
+			res = append(res, auction.NativeBid{Value: step.Price, Quantity: step.Quantity})
+		}
+	}
+	return res
+}
+
+// ProofF fills the assignment of ProofF
+func (r *Round) ProofF() (*prooff.RegisterCircuit, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	rule, err := auction.ParseRule(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.Clearing()
+	if err != nil {
+		return nil, err
+	}
+
+	assignment := prooff.NewCircuit(len(r.Buyers), len(r.Sellers), rule)
+	assignment.Volume = note.Variable(c.Volume)
+	assignment.Buy_price = note.Variable(c.BuyPrice)
+	assignment.Sell_price = note.Variable(c.SellPrice)
+	assignment.G = point(r.Buyers[0].G)
+	assignment.G_b = point(r.Buyers[0].G_b)
+	assignment.Slot = note.Variable(r.Buyers[0].Bid.Slot)
+	assignment.Asset = note.Variable(r.Buyers[0].N_in.T[0])
+	for i := range r.Buyers {
+		k := i * bid.Steps
+		if assignment.Buyers[i], err = r.Buyers[i].bidder(c.Buys[k:k+bid.Steps], c.BuyPrices[k:k+bid.Steps]); err != nil {
+			return nil, err
+		}
+	}
+	for j := range r.Sellers {
+		k := j * bid.Steps
+		if assignment.Sellers[j], err = r.Sellers[j].bidder(c.Sells[k:k+bid.Steps], c.SellPrices[k:k+bid.Steps]); err != nil {
+			return nil, err
+		}
+	}
+	return assignment, nil
+}
+
+// bidder fills the values of the participant in ProofF, given the quantity
+// traded and the price of each unit for each step of its bid
+func (s *Secrets) bidder(allocations, prices []fr.Element) (prooff.Bidder, error) {
+	var allocation, payment fr.Element
+	for j := range allocations {
+		var amount fr.Element
+		amount.Mul(&allocations[j], &prices[j])
+		allocation.Add(&allocation, &allocations[j])
+		payment.Add(&payment, &amount)
+	}
+	settled, err := s.Settle(payment)
+	if err != nil {
+		return prooff.Bidder{}, err
+	}
+	inst, err := settled.decrypted()
+	if err != nil {
+		return prooff.Bidder{}, err
+	}
+	b := prooff.Bidder{
+		Cm_in:      note.Variable(inst.Cm_in),
+		Cm_out:     note.Variable(inst.Cm_out),
+		Sn_in:      note.Variable(inst.Sn_in),
+		Nk_in_enc:  note.Variable(inst.Nk_in_enc),
+		Pk_out_enc: note.Variable(inst.Pk_out_enc),
+		B_enc:      variables(inst.B_enc),
+		Tag:        note.Variable(inst.Tag),
+		G_r:        point(inst.G_r),
+		G_r_b:      point(inst.G_r_b),
+
+		N_in:  settled.N_in.Note(),
+		N_out: settled.N_out.Note(),
+		B_i:   settled.Bid.Variable(),
+
+		Allocation: note.Variable(allocation),
+		Payment:    note.Variable(payment),
+	}
+	for j := range allocations {
+		b.Allocations[j] = note.Variable(allocations[j])
+		b.Prices[j] = note.Variable(prices[j])
+	}
+	return b, nil
+}
+
+// Settle returns the secrets whose note N_out holds the value of N_in less the
+// payment of a buyer, or plus the payment of a seller, as ProofF creates it.
+// The participant computes its new note from the payment published by ProofF.
+func (s Secrets) Settle(payment fr.Element) (Secrets, error) {
+	value := s.N_in.T[1]
+	if s.Bid.Side.IsUint64() && s.Bid.Side.Uint64() == bid.Buy {
+		if value.Cmp(&payment) < 0 {
+			return s, fmt.Errorf("N_in holds %s, less than the payment %s", value.String(), payment.String())
+		}
+		value.Sub(&value, &payment)
+	} else {
+		value.Add(&value, &payment)
+	}
+	s.N_out.T = [2]fr.Element{s.N_in.T[0], value}
+	s.DeriveOut()
+	return s, checkValues(&s.N_out)
+}
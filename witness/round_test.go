@@ -0,0 +1,128 @@
+package witness
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/auction"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooff"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+func TestRoundSolved(t *testing.T) {
+	for _, name := range []string{"uniform", "pay-as-bid", "mcafee", "k-double:1/2"} {
+		r, err := RandomRound(2, 2, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assignment, err := r.ProofF()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rule, _ := auction.ParseRule(name)
+		if err := test.IsSolved(prooff.NewCircuit(2, 2, rule), assignment, ecc.BLS12_377.ScalarField()); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+// tradingRound returns a round of one buyer and one seller trading 10 units
+// at 3 under the uniform rule
+func tradingRound(t *testing.T) Round {
+	t.Helper()
+	r, err := RandomRound(1, 1, "uniform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot := r.Buyers[0].Bid.Slot.Uint64()
+	if r.Buyers[0].Bid, err = bid.NewNativeBid(bid.Buy, slot, []uint64{10}, []uint64{5}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Sellers[0].Bid, err = bid.NewNativeBid(bid.Sell, slot, []uint64{10}, []uint64{3}); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRoundTampered(t *testing.T) {
+	field := ecc.BLS12_377.ScalarField()
+	rule, _ := auction.ParseRule("uniform")
+	r := tradingRound(t)
+	assignment, err := r.ProofF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(prooff.NewCircuit(1, 1, rule), assignment, field); err != nil {
+		t.Fatal(err)
+	}
+	// the buyer pays 30 and the seller receives them, at the clearing price 3
+	if assignment.Buy_price.(*big.Int).Int64() != 3 || assignment.Sell_price.(*big.Int).Int64() != 3 {
+		t.Fatalf("got the clearing prices %v and %v, expected 3", assignment.Buy_price, assignment.Sell_price)
+	}
+	var payment, buyer, seller fr.Element
+	payment.SetUint64(30)
+	buyer.Sub(&r.Buyers[0].N_in.T[1], &payment)
+	seller.Add(&r.Sellers[0].N_in.T[1], &payment)
+	if v := assignment.Buyers[0].N_out.T[1]; note.Variable(buyer).(*big.Int).Cmp(v.(*big.Int)) != 0 {
+		t.Fatal("the note of the buyer doesn't pay 30")
+	}
+	if v := assignment.Sellers[0].N_out.T[1]; note.Variable(seller).(*big.Int).Cmp(v.(*big.Int)) != 0 {
+		t.Fatal("the note of the seller doesn't receive 30")
+	}
+
+	other, err := note.RandomNativeNote()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tamper := range map[string]func(c *prooff.RegisterCircuit){
+		"another note spent": func(c *prooff.RegisterCircuit) {
+			c.Buyers[0].N_in = other.Note()
+		},
+		"another Cm_in": func(c *prooff.RegisterCircuit) {
+			c.Sellers[0].Cm_in = note.Variable(other.Cm)
+		},
+		"another clearing price": func(c *prooff.RegisterCircuit) {
+			c.Buy_price = 4
+		},
+		"another asset": func(c *prooff.RegisterCircuit) {
+			c.Asset = note.Variable(other.T[0])
+		},
+		"the buyer doesn't pay": func(c *prooff.RegisterCircuit) {
+			// the note given back by ProofDraw
+			inst, err := r.Buyers[0].decrypted()
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Buyers[0].N_out = r.Buyers[0].N_out.Note()
+			c.Buyers[0].Cm_out = note.Variable(inst.Cm_out)
+		},
+		"the buyer can't pay": func(c *prooff.RegisterCircuit) {
+			s := r.Buyers[0]
+			s.fund(s.N_in.T[0], *new(fr.Element).SetUint64(20))
+			s.N_out.T[1].Sub(&s.N_out.T[1], &payment)
+			s.DeriveOut()
+			c.Buyers[0].N_in, c.Buyers[0].Cm_in = s.N_in.Note(), note.Variable(s.N_in.Cm)
+			c.Buyers[0].N_out, c.Buyers[0].Cm_out = s.N_out.Note(), note.Variable(s.N_out.Cm)
+		},
+	} {
+		c, err := r.ProofF()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tamper(c)
+		if err := test.IsSolved(prooff.NewCircuit(1, 1, rule), c, field); err == nil {
+			t.Errorf("%s is accepted", name)
+		}
+	}
+
+	// the witness refuses a buyer whose note can't pay
+	r.Buyers[0].fund(r.Buyers[0].N_in.T[0], *new(fr.Element).SetUint64(20))
+	if _, err := r.ProofF(); err == nil {
+		t.Error("a buyer whose note can't pay is settled")
+	}
+}
@@ -0,0 +1,396 @@
+package witness
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooftx"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/merkle"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// Transfer gathers the secrets of a ProofTx
+type Transfer struct {
+	// notes spent
+	Old []note.NativeNote
+	// authentication paths of the commitments of the old notes in the tree of
+	// root Rt
+	Witnesses []merkle.Witness
+	Rt        fr.Element
+	// notes created, only T, Pk, G_d and R are used: the rho of the new notes is
+	// derived from the serial numbers of the old ones
+	New []note.NativeNote
+	// bid b
+	B fr.Element
+	// randomness r used to compute g_r
+	R fr.Element
+	// public generator g and auctioneer key g_b
+	G   edwards.PointAffine
+	G_b edwards.PointAffine
+	// public values entering and leaving the transaction, and fee paid to the
+	// operator, all of asset Asset_pub
+	Asset_pub fr.Element
+	V_pub_in  fr.Element
+	V_pub_out fr.Element
+	Fee       fr.Element
+}
+
+// RandomTransfer draws l notes of up to two assets, appends them to a merkle
+// tree of depth h among other commitments, and splits the total value of each
+// asset among the m new notes, the j-th new note being of the same asset as the
+// j-th old note. A public value enters the transaction in the asset of the
+// first note, and a fee and a public value leave it.
+func RandomTransfer(l, m, h int) (Transfer, error) {
+	var t Transfer
+	if l < 1 || m < 1 || h < 1 || h > 62 || l > 1<<h {
+		return t, fmt.Errorf("invalid transfer shape l=%d m=%d h=%d", l, m, h)
+	}
+
+	// every asset of the old notes must be given to some new note
+	assets := make([]fr.Element, min(l, m, 2))
+	for a := range assets {
+		n, err := note.RandomNativeNote()
+		if err != nil {
+			return t, err
+		}
+		assets[a] = n.T[0]
+	}
+
+	// the values of the old notes and the public value are bounded so that the
+	// total of each asset fits in note.ValueBits bits
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(note.ValueBits))
+	bound.Div(bound, big.NewInt(int64(l+1)))
+	totals := make([]*big.Int, len(assets))
+	for a := range totals {
+		totals[a] = new(big.Int)
+	}
+	t.Asset_pub = assets[0]
+	v, err := rand.Int(rand.Reader, bound)
+	if err != nil {
+		return t, err
+	}
+	t.V_pub_in.SetBigInt(v)
+	totals[0].Add(totals[0], v)
+	t.Old = make([]note.NativeNote, l)
+	for i := range t.Old {
+		n, err := note.RandomNativeNote()
+		if err != nil {
+			return t, err
+		}
+		v, err := rand.Int(rand.Reader, bound)
+		if err != nil {
+			return t, err
+		}
+		a := i % len(assets)
+		n.T[0] = assets[a]
+		n.T[1].SetBigInt(v)
+		t.Old[i] = note.NewNativeNote(n.T, n.Sk, n.Rho, n.R)
+		totals[a].Add(totals[a], v)
+	}
+	if err := t.appendOld(h); err != nil {
+		return t, err
+	}
+
+	// the fee and the public value leaving take up to a quarter of the total
+	// each
+	for _, e := range []*fr.Element{&t.Fee, &t.V_pub_out} {
+		v, err := rand.Int(rand.Reader, new(big.Int).Add(new(big.Int).Rsh(totals[0], 2), big.NewInt(1)))
+		if err != nil {
+			return t, err
+		}
+		e.SetBigInt(v)
+		totals[0].Sub(totals[0], v)
+	}
+
+	t.New = make([]note.NativeNote, m)
+	for j := range t.New {
+		n, err := note.RandomNativeNote()
+		if err != nil {
+			return t, err
+		}
+		// the new notes j, j+len(assets), ... share the total of their asset,
+		// the last one taking the remainder
+		a := j % len(assets)
+		count := int64((m - a + len(assets) - 1) / len(assets))
+		share := new(big.Int).Div(totals[a], big.NewInt(count))
+		n.T[0] = assets[a]
+		if j+len(assets) >= m {
+			n.T[1].SetBigInt(new(big.Int).Sub(totals[a], new(big.Int).Mul(share, big.NewInt(count-1))))
+		} else {
+			n.T[1].SetBigInt(share)
+		}
+		t.New[j] = note.NewNativeNote(n.T, n.Sk, n.Rho, n.R)
+	}
+
+	// the bid b fits in note.ValueBits bits, as the amounts
+	b, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(note.ValueBits)))
+	if err != nil {
+		return t, err
+	}
+	t.B.SetBigInt(b)
+	t.R, t.G, t.G_b, err = randomEncryption()
+	return t, err
+}
+
+// checkConservation returns an error unless, for each asset, the values of the
+// old notes and of the new notes sum up to the same amount, which fits in
+// note.ValueBits bits, as ProofTx asserts. The public values and the fee count
+// as notes of asset Asset_pub.
+func (t *Transfer) checkConservation() error {
+	for _, v := range []struct {
+		name string
+		e    *fr.Element
+	}{{"v_pub_in", &t.V_pub_in}, {"v_pub_out", &t.V_pub_out}, {"the fee", &t.Fee}} {
+		if err := note.CheckValue(v.name, v.e); err != nil {
+			return err
+		}
+	}
+	in := make([][2]fr.Element, 0, len(t.Old)+1)
+	for i := range t.Old {
+		in = append(in, t.Old[i].T)
+	}
+	in = append(in, [2]fr.Element{t.Asset_pub, t.V_pub_in})
+	out := make([][2]fr.Element, 0, len(t.New)+2)
+	for j := range t.New {
+		out = append(out, t.New[j].T)
+	}
+	out = append(out, [2]fr.Element{t.Asset_pub, t.V_pub_out}, [2]fr.Element{t.Asset_pub, t.Fee})
+
+	left_sum := make(map[fr.Element]*fr.Element)
+	right_sum := make(map[fr.Element]*fr.Element)
+	for i := range in {
+		if left_sum[in[i][0]] == nil {
+			left_sum[in[i][0]], right_sum[in[i][0]] = new(fr.Element), new(fr.Element)
+		}
+		left_sum[in[i][0]].Add(left_sum[in[i][0]], &in[i][1])
+	}
+	for j := range out {
+		sum, ok := right_sum[out[j][0]]
+		if !ok {
+			return fmt.Errorf("new note %d is of an asset that no old note holds", j)
+		}
+		sum.Add(sum, &out[j][1])
+	}
+	for asset, sum := range left_sum {
+		if !sum.Equal(right_sum[asset]) {
+			return fmt.Errorf("balance is not preserved for asset %s", asset.String())
+		}
+		if err := note.CheckValue("the value transferred", sum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDistinct returns an error if two old notes share their commitment or
+// their serial number, which ProofTx asserts to be distinct
+func (t *Transfer) checkDistinct() error {
+	Cm_old := make(map[fr.Element]int, len(t.Old))
+	Sn_old := make(map[fr.Element]int, len(t.Old))
+	for i := range t.Old {
+		if k, ok := Cm_old[t.Old[i].Cm]; ok {
+			return fmt.Errorf("old notes %d and %d are the same note", k, i)
+		}
+		Sn := t.Old[i].SerialNumber()
+		if k, ok := Sn_old[Sn]; ok {
+			return fmt.Errorf("old notes %d and %d share their serial number", k, i)
+		}
+		Cm_old[t.Old[i].Cm], Sn_old[Sn] = i, i
+	}
+	return nil
+}
+
+// created returns the new notes as created by ProofTx, whose rho is derived
+// from the serial numbers of the old notes
+func (t *Transfer) created() []note.NativeNote {
+	Sn_old := make([]fr.Element, len(t.Old))
+	for i := range t.Old {
+		Sn_old[i] = t.Old[i].SerialNumber()
+	}
+	New := make([]note.NativeNote, len(t.New))
+	for j := range t.New {
+		New[j] = t.New[j]
+		New[j].Rho = note.NativeDeriveRho(j, Sn_old...)
+		New[j].Cm = note.NativeCommitment(New[j].T, New[j].R, New[j].Rho, New[j].Pk)
+	}
+	return New
+}
+
+// Outputs encrypts each new note, as created by ProofTx, to the address of its
+// recipient, who finds it back with note.Scan
+func (t *Transfer) Outputs() ([]note.EncryptedNote, error) {
+	New := t.created()
+	res := make([]note.EncryptedNote, len(New))
+	for j := range New {
+		var err error
+		if res[j], err = New[j].Encrypt(); err != nil {
+			return nil, fmt.Errorf("new note %d: %w", j, err)
+		}
+	}
+	return res, nil
+}
+
+// appendOld appends the commitments of the old notes to a fresh tree of depth
+// h, each one after a random commitment while there is room left, and keeps
+// their witnesses up to date
+func (t *Transfer) appendOld(h int) error {
+	tree, err := merkle.NewTree(h)
+	if err != nil {
+		return err
+	}
+	t.Witnesses = make([]merkle.Witness, 0, len(t.Old))
+	appendLeaf := func(leaf fr.Element) error {
+		if _, err := tree.Append(leaf); err != nil {
+			return err
+		}
+		for i := range t.Witnesses {
+			if err := t.Witnesses[i].Update(tree); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := range t.Old {
+		if tree.Size()+uint64(len(t.Old)-i) < 1<<h {
+			other, err := note.RandomNativeNote()
+			if err != nil {
+				return err
+			}
+			if err := appendLeaf(other.Cm); err != nil {
+				return err
+			}
+		}
+		if err := appendLeaf(t.Old[i].Cm); err != nil {
+			return err
+		}
+		w, err := tree.Witness(t.Old[i].Cm)
+		if err != nil {
+			return err
+		}
+		t.Witnesses = append(t.Witnesses, w)
+	}
+	t.Rt = tree.Root()
+	return nil
+}
+
+// ProofTx fills the assignment of the ProofTx circuit spending the old notes
+// and creating the new ones. The merkle tree depth is the one of the witnesses
+// (see prooftx.NewCircuit).
+func (t *Transfer) ProofTx() (*prooftx.RegisterCircuit, error) {
+	l, m := len(t.Old), len(t.New)
+	if l < 1 || m < 1 || len(t.Witnesses) != l {
+		return nil, fmt.Errorf("invalid ProofTx shape l=%d m=%d witnesses=%d", l, m, len(t.Witnesses))
+	}
+	h := len(t.Witnesses[0].Siblings)
+	if h < 1 {
+		return nil, fmt.Errorf("invalid merkle tree depth %d", h)
+	}
+	if err := checkScalar("r", &t.R); err != nil {
+		return nil, err
+	}
+	notes := make([]*note.NativeNote, 0, l+m)
+	for i := range t.Old {
+		notes = append(notes, &t.Old[i])
+	}
+	for j := range t.New {
+		notes = append(notes, &t.New[j])
+	}
+	if err := note.CheckValue("b", &t.B); err != nil {
+		return nil, err
+	}
+	if err := checkValues(notes...); err != nil {
+		return nil, err
+	}
+
+	// a note spent twice would count its value twice in the balance
+	if err := t.checkDistinct(); err != nil {
+		return nil, err
+	}
+
+	// check if the balance of each asset is preserved
+	if err := t.checkConservation(); err != nil {
+		return nil, err
+	}
+
+	return t.assign(h)
+}
+
+// assign fills the assignment of the ProofTx circuit of merkle tree depth h,
+// without checking the secrets
+func (t *Transfer) assign(h int) (*prooftx.RegisterCircuit, error) {
+	l, m := len(t.Old), len(t.New)
+	assignment := prooftx.NewCircuit(l, m, h)
+
+	//sn_old and Rho_new_j = H(sn_old_1, ..., sn_old_l, j)
+	for i := range t.Old {
+		assignment.Sn_old_list[i] = note.Variable(t.Old[i].SerialNumber())
+		assignment.N_old_list[i] = t.Old[i].Full()
+	}
+	New := t.created()
+	for j := range New {
+		assignment.Cm_new_list[j] = note.Variable(New[j].Cm)
+		assignment.N_new_list[j] = New[j].Full()
+	}
+
+	// g_r == g^r and g_r_b == g_b^r
+	var G_r, G_r_b edwards.PointAffine
+	G_r.ScalarMultiplication(&t.G, t.R.BigInt(new(big.Int)))
+	G_r_b.ScalarMultiplication(&t.G_b, t.R.BigInt(new(big.Int)))
+
+	//(C, Tag) == Enc(g_r_b, nk_old_1||...||nk_old_l||pk_new_1||...||pk_new_m||b)
+	plaintext := make([]fr.Element, 0, l+m+1)
+	for i := range t.Old {
+		keys := note.NewNativeKeys(t.Old[i].Sk)
+		plaintext = append(plaintext, keys.Nk)
+	}
+	for j := range New {
+		plaintext = append(plaintext, New[j].Pk)
+	}
+	plaintext = append(plaintext, t.B)
+	C, Tag := aead.NativeEncrypt(G_r_b, aead.NonceTransfer, plaintext)
+	for i := range t.Old {
+		assignment.Nk_in_enc_list[i] = note.Variable(C[i])
+	}
+	for j := range New {
+		assignment.Pk_out_enc_list[j] = note.Variable(C[l+j])
+	}
+	assignment.B_enc = note.Variable(C[l+m])
+	assignment.Tag = note.Variable(Tag)
+
+	// merkle proofs of the old commitments
+	assignment.Rt = note.Variable(t.Rt)
+	for i := range t.Witnesses {
+		w := &t.Witnesses[i]
+		if len(w.Siblings) != h {
+			return nil, fmt.Errorf("witness %d has depth %d instead of %d", i, len(w.Siblings), h)
+		}
+		if !w.Leaf.Equal(&t.Old[i].Cm) {
+			return nil, fmt.Errorf("witness %d does not authenticate old note %d", i, i)
+		}
+		if root := w.Root(); !root.Equal(&t.Rt) {
+			return nil, fmt.Errorf("witness %d does not match the merkle root", i)
+		}
+		for k, bit := range w.Path() {
+			assignment.Path_list[i][k] = bit
+			assignment.Siblings_list[i][k] = note.Variable(w.Siblings[k])
+		}
+	}
+
+	assignment.G_r = point(G_r)
+	assignment.G = point(t.G)
+	assignment.G_b = point(t.G_b)
+	assignment.B_i = note.Variable(t.B)
+	assignment.G_r_b = point(G_r_b)
+	assignment.R = note.Variable(t.R)
+	assignment.Asset_pub = note.Variable(t.Asset_pub)
+	assignment.V_pub_in = note.Variable(t.V_pub_in)
+	assignment.V_pub_out = note.Variable(t.V_pub_out)
+	assignment.Fee = note.Variable(t.Fee)
+
+	return assignment, nil
+}
@@ -0,0 +1,46 @@
+package witness
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/prooftx"
+)
+
+func TestTransferSolved(t *testing.T) {
+	tr, err := RandomTransfer(2, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, err := tr.ProofTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(prooftx.NewCircuit(2, 3, 3), assignment, ecc.BLS12_377.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferDoubleSpend(t *testing.T) {
+	tr, err := RandomTransfer(1, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same note spent twice pays for twice its value
+	tr.Old = append(tr.Old, tr.Old[0])
+	tr.Witnesses = append(tr.Witnesses, tr.Witnesses[0])
+	tr.New[0].T[1].Add(&tr.New[0].T[1], &tr.Old[0].T[1])
+
+	if _, err := tr.ProofTx(); err == nil {
+		t.Fatal("the builder accepted a note spent twice")
+	}
+	assignment, err := tr.assign(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(prooftx.NewCircuit(2, 1, 3), assignment, ecc.BLS12_377.ScalarField()); err == nil {
+		t.Fatal("ProofTx is solved by a note spent twice")
+	}
+}
@@ -0,0 +1,297 @@
+// Package witness derives, from the secrets of a participant, every instance
+// value expected by ProofReg, ProofDraw, ProofF and ProofTx (commitments,
+// serial numbers, g_r, g_r_b and the encrypted fields) and fills the matching
+// circuit assignments. The assignment of ProofF is filled from the secrets of
+// every participant of the round.
+package witness
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	edwards "github.com/consensys/gnark-crypto/ecc/bls12-377/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/aead"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/bid"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofdraw"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/circuits/proofreg"
+	"github.com/HamzaZF/Privacy-Preserving-Exchange-Mechanism/note"
+)
+
+// Secrets are the values known by the participant
+type Secrets struct {
+	// note registered by ProofReg then spent by ProofDraw, ProofF and ProofTx
+	N_in note.NativeNote
+	// note created by ProofDraw, ProofF and ProofTx, N_out.Pk is pk_out and
+	// N_out.Rho is derived from the serial number of N_in (see DeriveOut)
+	N_out note.NativeNote
+	// nullifier key of N_in, set only when its spending key N_in.Sk is unknown:
+	// the auctioneer learns nk_in from the registration, not sk_in
+	Nk_in fr.Element
+	// bid, packed into b
+	Bid bid.NativeBid
+	// randomness r used to compute g_r
+	R fr.Element
+	// g_r and g_r_b, set only when r is unknown: the auctioneer reads g_r from
+	// the registration and computes g_r_b as g_r^b
+	G_r   edwards.PointAffine
+	G_r_b edwards.PointAffine
+	// public generator g and auctioneer key g_b
+	G   edwards.PointAffine
+	G_b edwards.PointAffine
+}
+
+// Instance gathers the public values derived from the secrets
+type Instance struct {
+	Cm_in  fr.Element
+	Sn_in  fr.Element
+	Cm_out fr.Element
+
+	Nk_in_enc  fr.Element
+	Pk_out_enc fr.Element
+	B_enc      [bid.Steps]fr.Element
+	Tag        fr.Element
+
+	G_r   edwards.PointAffine
+	G_r_b edwards.PointAffine
+}
+
+// RandomSecrets draws the secrets of a participant whose whole note value is
+// transferred to N_out, bidding on a random side and delivery slot
+func RandomSecrets() (Secrets, error) {
+	var s Secrets
+	var err error
+	if s.N_in, err = note.RandomNativeNote(); err != nil {
+		return s, err
+	}
+	out, err := note.RandomNativeNote()
+	if err != nil {
+		return s, err
+	}
+	s.N_out = note.NewNativeNote(s.N_in.T, out.Sk, out.Rho, out.R)
+	s.DeriveOut()
+	side, err := rand.Int(rand.Reader, big.NewInt(2))
+	if err != nil {
+		return s, err
+	}
+	slot, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(bid.SlotBits)))
+	if err != nil {
+		return s, err
+	}
+	if s.Bid, err = bid.RandomNativeBid(side.Uint64(), slot.Uint64()); err != nil {
+		return s, err
+	}
+	s.R, s.G, s.G_b, err = randomEncryption()
+	return s, err
+}
+
+// randomEncryption draws a randomness r, and the key g_b of a random
+// auctioneer for the base point g of the twisted Edwards curve of BLS12-377
+func randomEncryption() (R fr.Element, G, G_b edwards.PointAffine, err error) {
+	curve := edwards.GetEdwardsCurve()
+	r, err := randomScalar(&curve.Order)
+	if err != nil {
+		return
+	}
+	R.SetBigInt(r)
+	_, G, G_b, err = RandomKey()
+	return
+}
+
+// RandomKey draws the secret key b of an auctioneer, and returns it with the
+// base point g of the twisted Edwards curve of BLS12-377 and the public key
+// g_b == g^b
+func RandomKey() (B fr.Element, G, G_b edwards.PointAffine, err error) {
+	curve := edwards.GetEdwardsCurve()
+	b, err := randomScalar(&curve.Order)
+	if err != nil {
+		return
+	}
+	B.SetBigInt(b)
+	G = curve.Base
+	G_b.ScalarMultiplication(&G, b)
+	return
+}
+
+// randomScalar draws a scalar in [1, order)
+func randomScalar(order *big.Int) (*big.Int, error) {
+	s, err := rand.Int(rand.Reader, new(big.Int).Sub(order, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return s.Add(s, big.NewInt(1)), nil
+}
+
+// Instance computes the values exposed by the circuits
+func (s *Secrets) Instance() (Instance, error) {
+	if err := checkScalar("r", &s.R); err != nil {
+		return Instance{}, err
+	}
+	// g_r == g^r and g_r_b == g_b^r, the auctioneer computing it as g_r^b
+	var G_r, G_r_b edwards.PointAffine
+	G_r.ScalarMultiplication(&s.G, s.R.BigInt(new(big.Int)))
+	G_r_b.ScalarMultiplication(&s.G_b, s.R.BigInt(new(big.Int)))
+	return s.instance(G_r, G_r_b)
+}
+
+// decrypted computes the values exposed by the circuits as the auctioneer
+// does, from g_r and g_r_b when r is unknown
+func (s *Secrets) decrypted() (Instance, error) {
+	if !s.R.IsZero() {
+		return s.Instance()
+	}
+	return s.instance(s.G_r, s.G_r_b)
+}
+
+// instance computes the values exposed by the circuits, given g_r and g_r_b
+func (s *Secrets) instance(G_r, G_r_b edwards.PointAffine) (Instance, error) {
+	var inst Instance
+
+	if err := s.Bid.Check(); err != nil {
+		return inst, err
+	}
+	b := s.Bid.Pack()
+	if err := checkValues(&s.N_in, &s.N_out); err != nil {
+		return inst, err
+	}
+	if rho := note.NativeDeriveRho(0, s.sn()); !s.N_out.Rho.Equal(&rho) {
+		return inst, fmt.Errorf("the rho of N_out must be derived from the serial number of N_in")
+	}
+
+	inst.Cm_in = s.N_in.Cm
+	inst.Sn_in = s.sn()
+	inst.Cm_out = s.N_out.Cm
+	inst.G_r, inst.G_r_b = G_r, G_r_b
+
+	// nk_in, pk_out then the steps of the bid are encrypted under g_r_b
+	plaintext := append([]fr.Element{s.nk(), s.N_out.Pk}, b[:]...)
+	C, Tag := aead.NativeEncrypt(inst.G_r_b, aead.NonceRegistration, plaintext)
+	inst.Nk_in_enc, inst.Pk_out_enc, inst.Tag = C[0], C[1], Tag
+	copy(inst.B_enc[:], C[2:])
+
+	return inst, nil
+}
+
+// DeriveOut sets the rho of N_out to H(sn_in, 0), as ProofDraw and ProofF
+// require, and recomputes its commitment
+func (s *Secrets) DeriveOut() {
+	s.N_out.Rho = note.NativeDeriveRho(0, s.sn())
+	s.N_out.Cm = note.NativeCommitment(s.N_out.T, s.N_out.R, s.N_out.Rho, s.N_out.Pk)
+}
+
+// nk returns the nullifier key of N_in, derived from its spending key when it
+// is known
+func (s *Secrets) nk() fr.Element {
+	if s.N_in.Sk.IsZero() {
+		return s.Nk_in
+	}
+	return note.NewNativeKeys(s.N_in.Sk).Nk
+}
+
+// sn returns the serial number of N_in
+func (s *Secrets) sn() fr.Element {
+	return note.NativeSerialNumber(s.nk(), s.N_in.Rho)
+}
+
+// checkScalar ensures e is a non-zero scalar of the twisted Edwards curve of
+// BLS12-377, so that g_r and g_r_b are not the identity
+func checkScalar(name string, e *fr.Element) error {
+	curve := edwards.GetEdwardsCurve()
+	if e.IsZero() || e.BigInt(new(big.Int)).Cmp(&curve.Order) >= 0 {
+		return fmt.Errorf("%s must be a non-zero scalar of the twisted Edwards curve of BLS12-377", name)
+	}
+	return nil
+}
+
+// checkValues ensures the values of the notes fit in note.ValueBits bits, as
+// the circuits require
+func checkValues(notes ...*note.NativeNote) error {
+	for _, n := range notes {
+		if err := n.CheckValues(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// variables converts the packed steps of a bid into a circuit assignment
+func variables(b [bid.Steps]fr.Element) [bid.Steps]frontend.Variable {
+	var res [bid.Steps]frontend.Variable
+	for j := range b {
+		res[j] = note.Variable(b[j])
+	}
+	return res
+}
+
+// point converts a native point into a circuit assignment
+func point(p edwards.PointAffine) twistededwards.Point {
+	return twistededwards.Point{X: note.Variable(p.X), Y: note.Variable(p.Y)}
+}
+
+// ProofReg fills the assignment of ProofReg, which needs the spending key of N_in
+func (s *Secrets) ProofReg() (*proofreg.RegisterCircuit, error) {
+	if s.N_in.Sk.IsZero() {
+		return nil, fmt.Errorf("ProofReg needs the spending key of N_in")
+	}
+	inst, err := s.Instance()
+	if err != nil {
+		return nil, err
+	}
+	return &proofreg.RegisterCircuit{
+		Cm_in:      note.Variable(inst.Cm_in),
+		Nk_in_enc:  note.Variable(inst.Nk_in_enc),
+		Pk_out_enc: note.Variable(inst.Pk_out_enc),
+		B_enc:      variables(inst.B_enc),
+		Tag:        note.Variable(inst.Tag),
+		G_r:        point(inst.G_r),
+		G:          point(s.G),
+		G_b:        point(s.G_b),
+
+		N_in:   s.N_in.Note(),
+		Sk_in:  note.Variable(s.N_in.Sk),
+		G_d_in: point(s.N_in.G_d),
+		B_i:    s.Bid.Variable(),
+		G_r_b:  point(inst.G_r_b),
+		Pk_out: note.Variable(s.N_out.Pk),
+		R:      note.Variable(s.R),
+	}, nil
+}
+
+// ProofDraw fills the assignment of ProofDraw, which needs the spending key of
+// N_in and gives its value back in N_out
+func (s *Secrets) ProofDraw() (*proofdraw.RegisterCircuit, error) {
+	if s.N_in.Sk.IsZero() {
+		return nil, fmt.Errorf("ProofDraw needs the spending key of N_in")
+	}
+	if s.N_out.T != s.N_in.T {
+		return nil, fmt.Errorf("ProofDraw gives the asset and the value of N_in back in N_out")
+	}
+	inst, err := s.Instance()
+	if err != nil {
+		return nil, err
+	}
+	return &proofdraw.RegisterCircuit{
+		Cm_in:      note.Variable(inst.Cm_in),
+		Cm_out:     note.Variable(inst.Cm_out),
+		Sn_in:      note.Variable(inst.Sn_in),
+		Nk_in_enc:  note.Variable(inst.Nk_in_enc),
+		Pk_out_enc: note.Variable(inst.Pk_out_enc),
+		B_enc:      variables(inst.B_enc),
+		Tag:        note.Variable(inst.Tag),
+		G_r:        point(inst.G_r),
+		G:          point(s.G),
+		G_b:        point(s.G_b),
+
+		N_in:   s.N_in.Note(),
+		N_out:  s.N_out.Note(),
+		Sk_in:  note.Variable(s.N_in.Sk),
+		G_d_in: point(s.N_in.G_d),
+		B_i:    s.Bid.Variable(),
+		G_r_b:  point(inst.G_r_b),
+		R:      note.Variable(s.R),
+	}, nil
+}